@@ -4,14 +4,23 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
 	"github.com/dnephin/proto-gen-html/tmpl"
 	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
 	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
 )
 
+// loadConfig returns the config from the conf file named by the conf parameter,
+// if there is one, and the other parameters. Parameters take precedence over
+// the conf file: url_root and template_root replace the fields of the config,
+// and template, target, and output replace the fields of the operation of the
+// conf file, or create the operation when there is no conf file. A conf file
+// with more than one operation can not be used with these parameters. Each
+// partial parameter is added to the Partials of the conf file.
 func loadConfig(request *plugin.CodeGeneratorRequest) (tmpl.Config, error) {
 	config := tmpl.Config{}
 	params := paramsToMap(request)
@@ -21,7 +30,7 @@ func loadConfig(request *plugin.CodeGeneratorRequest) (tmpl.Config, error) {
 		if err != nil {
 			return config, errors.Wrapf(err, "failed to read conf file %s", conf)
 		}
-		if err := json.Unmarshal(confData, &config); err != nil {
+		if err := unmarshalConfig(conf, confData, &config); err != nil {
 			return config, errors.Wrapf(err, "failed to unmarshal config %s", conf)
 		}
 
@@ -38,6 +47,20 @@ func loadConfig(request *plugin.CodeGeneratorRequest) (tmpl.Config, error) {
 		config.TemplateRoot = value
 	}
 
+	// Partials from parameters are added to those of the conf file.
+	config.Partials = append(config.Partials, paramLists(request)["partial"]...)
+
+	if err := applyOperationParams(&config, params); err != nil {
+		return config, err
+	}
+
+	if value, ok := params["builtin_template"]; ok {
+		config.BuiltinTemplate = value
+		if len(config.Operations) == 0 {
+			config.Operations = builtinOperations(request)
+		}
+	}
+
 	if config.TemplateRoot == "" {
 		var err error
 		config.TemplateRoot, err = os.Getwd()
@@ -45,25 +68,117 @@ func loadConfig(request *plugin.CodeGeneratorRequest) (tmpl.Config, error) {
 			return config, err
 		}
 	}
-
-	// TODO: set other config fields from params
 	return config, nil
 }
 
+// operationParams are the parameters which set the fields of an operation.
+var operationParams = []string{"template", "target", "output"}
+
+// applyOperationParams sets the fields of the operation of the config from the
+// template, target, and output parameters. An operation is added if the config
+// has none.
+func applyOperationParams(config *tmpl.Config, params map[string]string) error {
+	var found []string
+	for _, key := range operationParams {
+		if _, ok := params[key]; ok {
+			found = append(found, key)
+		}
+	}
+	if len(found) == 0 {
+		return nil
+	}
+
+	switch len(config.Operations) {
+	case 0:
+		config.Operations = []tmpl.OperationConfig{{}}
+	case 1:
+	default:
+		return errors.Errorf("the %s parameters can not be used with a conf file with %d operations",
+			strings.Join(found, ", "), len(config.Operations))
+	}
+
+	op := &config.Operations[0]
+	if value, ok := params["template"]; ok {
+		op.Template = value
+	}
+	if value, ok := params["target"]; ok {
+		op.Target = value
+	}
+	if value, ok := params["output"]; ok {
+		op.Output = value
+	}
+	return nil
+}
+
+// unmarshalConfig unmarshals the config file with the name. Files with a
+// .yaml or .yml extension are YAML, and all other files are JSON.
+func unmarshalConfig(name string, data []byte, config *tmpl.Config) error {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml":
+		return yaml.Unmarshal(data, config)
+	default:
+		return json.Unmarshal(data, config)
+	}
+}
+
+// builtinOperations returns an operation for each file being generated, which
+// renders the file with the builtin template to an html file of the same name.
+func builtinOperations(request *plugin.CodeGeneratorRequest) []tmpl.OperationConfig {
+	var operations []tmpl.OperationConfig
+	for _, name := range request.GetFileToGenerate() {
+		operations = append(operations, tmpl.OperationConfig{
+			Target: name,
+			Output: strings.TrimSuffix(name, path.Ext(name)) + ".html",
+		})
+	}
+	return operations
+}
+
+// multiValueParams are the parameters which can be repeated, e.g.
+// "partial=a.html,partial=b.html". They are returned by paramLists instead of
+// paramsToMap.
+var multiValueParams = map[string]bool{
+	"partial": true,
+}
+
 // paramsToMap parses the comma-separated command-line parameters passed to the
 // generator by protoc via r.GetParameters. Returned is a map of key=value
-// parameters with whitespace preserved.
+// parameters with whitespace preserved. If a key is repeated the last value is
+// used. Parameters in multiValueParams are not included.
 func paramsToMap(r *plugin.CodeGeneratorRequest) map[string]string {
 	items := strings.Split(r.GetParameter(), ",")
 	params := make(map[string]string, len(items))
 
 	for _, p := range items {
-		parts := strings.Split(p, "=")
-		var value string
-		if len(parts) > 1 {
-			value = strings.TrimSpace(parts[1])
+		key, value := parseParam(p)
+		if !multiValueParams[key] {
+			params[key] = value
 		}
-		params[strings.TrimSpace(parts[0])] = value
 	}
 	return params
 }
+
+// paramLists returns the values of each of the multiValueParams in the
+// parameters of r, in the order they are passed.
+func paramLists(r *plugin.CodeGeneratorRequest) map[string][]string {
+	params := make(map[string][]string)
+	for _, p := range strings.Split(r.GetParameter(), ",") {
+		if key, value := parseParam(p); multiValueParams[key] {
+			params[key] = append(params[key], value)
+		}
+	}
+	return params
+}
+
+// parseParam returns the key and value of a key=value parameter, with
+// surrounding whitespace removed. A parameter without a "=" has an empty
+// value.
+func parseParam(p string) (string, string) {
+	// Only the first "=" separates the key, so values can contain "=".
+	parts := strings.SplitN(p, "=", 2)
+	var value string
+	if len(parts) > 1 {
+		value = strings.TrimSpace(parts[1])
+	}
+	return strings.TrimSpace(parts[0]), value
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dnephin/proto-gen-html/tmpl"
+	"github.com/golang/protobuf/proto"
+	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
+)
+
+const jsonConfig = `{
+  "templateRoot": "templates",
+  "urlRoot": "/docs",
+  "int64AsString": false,
+  "packageOverview": {"foo": "foo.md"},
+  "markdown": {"taskLists": true},
+  "operations": [
+    {"template": "tmpl.html", "target": "foo.proto", "output": "foo.html"}
+  ]
+}`
+
+const yamlConfig = `
+templateRoot: templates
+urlRoot: /docs
+int64AsString: false
+packageOverview:
+  foo: foo.md
+markdown:
+  taskLists: true
+operations:
+  - template: tmpl.html
+    target: foo.proto
+    output: foo.html
+`
+
+func TestUnmarshalConfigYAMLMatchesJSON(t *testing.T) {
+	var fromJSON, fromYAML tmpl.Config
+	if err := unmarshalConfig("conf.json", []byte(jsonConfig), &fromJSON); err != nil {
+		t.Fatal(err)
+	}
+	if err := unmarshalConfig("conf.yaml", []byte(yamlConfig), &fromYAML); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fromJSON, fromYAML) {
+		t.Fatalf("expected the same config, got %+v and %+v", fromJSON, fromYAML)
+	}
+	if fromYAML.URLRoot != "/docs" || len(fromYAML.Operations) != 1 || !fromYAML.Markdown.TaskLists {
+		t.Fatalf("unexpected config %+v", fromYAML)
+	}
+}
+
+func TestUnmarshalConfigUnknownExtensionIsJSON(t *testing.T) {
+	var config tmpl.Config
+	if err := unmarshalConfig("conf", []byte(`{"URLRoot": "/docs"}`), &config); err != nil {
+		t.Fatal(err)
+	}
+	if config.URLRoot != "/docs" {
+		t.Fatalf("expected url root /docs, got %q", config.URLRoot)
+	}
+}
+
+func newParamsRequest(params string) *plugin.CodeGeneratorRequest {
+	return &plugin.CodeGeneratorRequest{
+		FileToGenerate: []string{"foo.proto"},
+		Parameter:      proto.String(params),
+	}
+}
+
+func TestLoadConfigOperationFromParams(t *testing.T) {
+	config, err := loadConfig(newParamsRequest("template=x.tmpl,target=foo.proto,output=foo.html,template_root=/templates"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []tmpl.OperationConfig{{Template: "x.tmpl", Target: "foo.proto", Output: "foo.html"}}
+	if !reflect.DeepEqual(config.Operations, expected) {
+		t.Fatalf("got %+v expected %+v", config.Operations, expected)
+	}
+	if config.TemplateRoot != "/templates" {
+		t.Fatalf("got template root %q", config.TemplateRoot)
+	}
+}
+
+func writeConf(t *testing.T, content string) (string, func()) {
+	dir, err := ioutil.TempDir("", "protoc-gen-html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(dir, "conf.json")
+	if err := ioutil.WriteFile(name, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return name, func() { os.RemoveAll(dir) }
+}
+
+func TestLoadConfigParamsOverrideConf(t *testing.T) {
+	conf, cleanup := writeConf(t, jsonConfig)
+	defer cleanup()
+
+	config, err := loadConfig(newParamsRequest("conf=" + conf + ",output=other.html,url_root=/api"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []tmpl.OperationConfig{{Template: "tmpl.html", Target: "foo.proto", Output: "other.html"}}
+	if !reflect.DeepEqual(config.Operations, expected) {
+		t.Fatalf("got %+v expected %+v", config.Operations, expected)
+	}
+	if config.URLRoot != "/api" {
+		t.Fatalf("got url root %q", config.URLRoot)
+	}
+}
+
+func TestLoadConfigParamsWithManyOperations(t *testing.T) {
+	conf, cleanup := writeConf(t, `{"operations": [{"output": "a.html"}, {"output": "b.html"}]}`)
+	defer cleanup()
+
+	_, err := loadConfig(newParamsRequest("conf=" + conf + ",template=x.tmpl"))
+	if err == nil || !strings.Contains(err.Error(), "the template parameters can not be used with a conf file with 2 operations") {
+		t.Fatalf("expected an error, got %v", err)
+	}
+}
+
+func TestParamsToMap(t *testing.T) {
+	params := paramsToMap(newParamsRequest("url_root=https://x/?a=b&c==d, flag ,data=YWJj=="))
+	expected := map[string]string{
+		"url_root": "https://x/?a=b&c==d",
+		"flag":     "",
+		"data":     "YWJj==",
+	}
+	if !reflect.DeepEqual(params, expected) {
+		t.Fatalf("got %q expected %q", params, expected)
+	}
+}
+
+func TestLoadConfigRepeatedPartials(t *testing.T) {
+	conf, cleanup := writeConf(t, `{"partials": ["common/*.html"]}`)
+	defer cleanup()
+
+	config, err := loadConfig(newParamsRequest("conf=" + conf + ", partial = a/*.html ,partial=b.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"common/*.html", "a/*.html", "b.html"}
+	if !reflect.DeepEqual(config.Partials, expected) {
+		t.Fatalf("got %q expected %q", config.Partials, expected)
+	}
+	if _, ok := paramsToMap(newParamsRequest("partial=a.html"))["partial"]; ok {
+		t.Fatal("expected partial to be excluded from the scalar params")
+	}
+}
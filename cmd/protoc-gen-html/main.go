@@ -2,24 +2,44 @@ package main
 
 import (
 	"bytes"
+	"flag"
+	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
 	"os"
 
 	"github.com/dnephin/proto-gen-html/tmpl"
 	"github.com/golang/protobuf/proto"
 	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 )
 
 func main() {
+	logLevel := flag.String("log-level", "info", "level of the log messages written to stderr")
+	showVersion := flag.Bool("version", false, "print the version and exit")
+	flag.Parse()
+	// Exit before reading the request, which blocks until protoc closes stdin.
+	if *showVersion {
+		fmt.Println(versionString())
+		return
+	}
 	setupLogging()
+	if err := setLogLevel(*logLevel); err != nil {
+		log.Fatal(err)
+	}
 
 	request, err := readRequest()
 	if err != nil {
 		log.Fatal(err)
 	}
+	// The log_level parameter is used when the level can not be set with a
+	// flag, because protoc does not pass arguments to plugins.
+	if level, ok := paramsToMap(request)["log_level"]; ok {
+		if err := setLogLevel(level); err != nil {
+			log.Fatal(err)
+		}
+	}
 
 	config, err := loadConfig(request)
 	if err != nil {
@@ -36,10 +56,20 @@ func main() {
 	}
 }
 
-// TODO: use logrus
+// setupLogging writes log messages to stderr, because stdout is reserved for
+// the response read by protoc.
 func setupLogging() {
-	log.SetFlags(0)
-	log.SetPrefix("protoc-gen-html: ")
+	log.SetOutput(os.Stderr)
+	log.SetFormatter(&log.TextFormatter{DisableTimestamp: true})
+}
+
+func setLogLevel(value string) error {
+	level, err := log.ParseLevel(value)
+	if err != nil {
+		return errors.Wrapf(err, "invalid log level")
+	}
+	log.SetLevel(level)
+	return nil
 }
 
 func readRequest() (*plugin.CodeGeneratorRequest, error) {
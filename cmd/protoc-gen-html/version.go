@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Build metadata set with ldflags, e.g.
+//
+//	go build -ldflags "-X main.version=v0.1.0 -X main.commit=$(git rev-parse --short HEAD)"
+var (
+	version = "unknown"
+	commit  = "unknown"
+	date    = "unknown"
+)
+
+// versionString returns the version, commit, and build date of the binary, and
+// the version of Go it was built with.
+func versionString() string {
+	return fmt.Sprintf("protoc-gen-html version %s (commit %s, built %s, %s)",
+		version, commit, date, runtime.Version())
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestVersionString(t *testing.T) {
+	defer func(v, c, d string) { version, commit, date = v, c, d }(version, commit, date)
+	version, commit, date = "v1.2.3", "abcdef0", "2018-09-01"
+
+	got := versionString()
+	for _, expected := range []string{"v1.2.3", "abcdef0", "2018-09-01", runtime.Version()} {
+		if !strings.Contains(got, expected) {
+			t.Errorf("expected %q in %q", expected, got)
+		}
+	}
+}
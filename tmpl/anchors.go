@@ -0,0 +1,85 @@
+package tmpl
+
+import (
+	"fmt"
+
+	"github.com/dnephin/proto-gen-html/util"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// slugAnchor returns the slug of the plain anchor of the symbol declared in
+// file. The anchors of all the symbols on the page of the file are assigned in
+// declaration order, so that a symbol always has the same anchor regardless of
+// the order in which anchors are requested. The first symbol with a slug gets
+// the slug, and the following symbols with the same slug get a "-1", "-2", ...
+// suffix.
+func (f *tmplFuncs) slugAnchor(symbolPath string, file *descriptor.FileDescriptorProto) string {
+	page := file
+	if f.singleDocument {
+		page = nil // every generated file is on the same page
+	}
+	anchors, ok := f.slugAnchors[page]
+	if !ok {
+		files := []*descriptor.FileDescriptorProto{file}
+		if f.singleDocument {
+			files = f.files
+		}
+		anchors = f.pageSlugAnchors(files)
+		if f.slugAnchors == nil {
+			f.slugAnchors = make(map[*descriptor.FileDescriptorProto]map[string]string)
+		}
+		f.slugAnchors[page] = anchors
+	}
+	if anchor, ok := anchors[symbolPath]; ok {
+		return anchor
+	}
+	return slug(f.plainAnchor(symbolPath, file))
+}
+
+// pageSlugAnchors returns the slugged anchors of the messages, enums,
+// services, and methods declared in files, by their fully-qualified name.
+func (f *tmplFuncs) pageSlugAnchors(files []*descriptor.FileDescriptorProto) map[string]string {
+	anchors := make(map[string]string)
+	seen := make(map[string]int)
+	add := func(file *descriptor.FileDescriptorProto, fullName string) {
+		anchor := slug(f.plainAnchor(fullName, file))
+		if n, ok := seen[anchor]; ok {
+			seen[anchor] = n + 1
+			anchor = fmt.Sprintf("%s-%d", anchor, n+1)
+		} else {
+			seen[anchor] = 0
+		}
+		anchors[fullName] = anchor
+	}
+
+	var addMessage func(*descriptor.FileDescriptorProto, *descriptor.DescriptorProto, string)
+	addMessage = func(file *descriptor.FileDescriptorProto, msg *descriptor.DescriptorProto, fullName string) {
+		if msg.GetOptions().GetMapEntry() {
+			return
+		}
+		add(file, fullName)
+		for _, nested := range msg.GetNestedType() {
+			addMessage(file, nested, fullName+"."+nested.GetName())
+		}
+		for _, enum := range msg.GetEnumType() {
+			add(file, fullName+"."+enum.GetName())
+		}
+	}
+
+	for _, file := range files {
+		for _, msg := range file.GetMessageType() {
+			addMessage(file, msg, util.FullName(file, msg.GetName()))
+		}
+		for _, enum := range file.GetEnumType() {
+			add(file, util.FullName(file, enum.GetName()))
+		}
+		for _, service := range file.GetService() {
+			fullName := util.FullName(file, service.GetName())
+			add(file, fullName)
+			for _, method := range service.GetMethod() {
+				add(file, fullName+"."+method.GetName())
+			}
+		}
+	}
+	return anchors
+}
@@ -0,0 +1,72 @@
+package tmpl
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestSlugAnchors(t *testing.T) {
+	file := &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+		MessageType: []*descriptor.DescriptorProto{
+			{
+				Name:       proto.String("Outer"),
+				NestedType: []*descriptor.DescriptorProto{{Name: proto.String("Inner")}},
+			},
+			{Name: proto.String("Outer_Inner")},
+			{Name: proto.String("OuterInner")},
+		},
+		Service: []*descriptor.ServiceDescriptorProto{{
+			Name:   proto.String("Things"),
+			Method: []*descriptor.MethodDescriptorProto{{Name: proto.String("Get")}},
+		}},
+	}
+	files := []*descriptor.FileDescriptorProto{file}
+	f := &tmplFuncs{
+		outputFile: "foo.html",
+		protoFiles: files,
+		files:      files,
+		config:     Config{SlugAnchors: true},
+	}
+
+	// Request the colliding anchors in reverse declaration order to show the
+	// suffix depends on the declaration order.
+	var tests = []struct {
+		symbol   string
+		expected string
+	}{
+		{".foo.Outer_Inner", "foo.html#outer-inner-1"},
+		{".foo.Outer.Inner", "foo.html#outer-inner"},
+		{".foo.OuterInner", "foo.html#outerinner"},
+		{".foo.Outer", "foo.html#outer"},
+	}
+	for _, test := range tests {
+		if got := f.typeURL(test.symbol); got != test.expected {
+			t.Errorf("%s: got %q expected %q", test.symbol, got, test.expected)
+		}
+	}
+
+	if got := f.declAnchor(file, "Outer_Inner"); got != "outer-inner-1" {
+		t.Errorf("got declAnchor %q expected %q", got, "outer-inner-1")
+	}
+	service := file.Service[0]
+	if got := f.methodURL(service, service.Method[0]); got != "foo.html#things-get" {
+		t.Errorf("got methodURL %q expected %q", got, "foo.html#things-get")
+	}
+}
+
+func TestSlugAnchorsDisabled(t *testing.T) {
+	file := &descriptor.FileDescriptorProto{
+		Name:        proto.String("foo.proto"),
+		Package:     proto.String("foo"),
+		MessageType: []*descriptor.DescriptorProto{{Name: proto.String("Outer_Inner")}},
+	}
+	files := []*descriptor.FileDescriptorProto{file}
+	f := &tmplFuncs{outputFile: "foo.html", protoFiles: files, files: files}
+	if got := f.typeURL(".foo.Outer_Inner"); got != "foo.html#Outer_Inner" {
+		t.Fatalf("got %q expected %q", got, "foo.html#Outer_Inner")
+	}
+}
@@ -0,0 +1,55 @@
+package tmpl
+
+import (
+	_ "embed" // for the builtin templates
+	"html/template"
+
+	"github.com/pkg/errors"
+)
+
+// defaultBuiltinTemplate is the name of the builtin template used when
+// Config.BuiltinTemplate is empty.
+const defaultBuiltinTemplate = "default"
+
+//go:embed builtin/default.html
+var defaultTemplate string
+
+// builtinTemplates are the templates included in the binary, keyed by the name
+// used for Config.BuiltinTemplate.
+var builtinTemplates = map[string]string{
+	defaultBuiltinTemplate: defaultTemplate,
+}
+
+// parseBuiltin parses the builtin template of the config into tmpl, and
+// returns the parsed template. The builtin templates are written with the
+// default delimiters, so they are parsed separately from tmpl, which may use
+// the custom delimiters of the operation, and then added to it.
+func (g *generator) parseBuiltin(tmpl *template.Template) (*template.Template, error) {
+	name := g.builtinTemplateName()
+	source, ok := builtinTemplates[name]
+	if !ok {
+		return nil, errors.Errorf("unknown builtin template %q", name)
+	}
+	builtin, err := template.New(name).
+		Delims("", "").
+		Funcs(newDefaultTemplateFuncs()).
+		Funcs(g.config.Funcs).
+		Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range builtin.Templates() {
+		if _, err := tmpl.AddParseTree(t.Name(), t.Tree); err != nil {
+			return nil, err
+		}
+	}
+	return tmpl.Lookup(name), nil
+}
+
+// builtinTemplateName returns the name of the builtin template of the config.
+func (g *generator) builtinTemplateName() string {
+	if g.config.BuiltinTemplate == "" {
+		return defaultBuiltinTemplate
+	}
+	return g.config.BuiltinTemplate
+}
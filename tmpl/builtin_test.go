@@ -0,0 +1,115 @@
+package tmpl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func newBuiltinTestFile() *descriptor.FileDescriptorProto {
+	return &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+		MessageType: []*descriptor.DescriptorProto{
+			{
+				Name: proto.String("Thing"),
+				Field: []*descriptor.FieldDescriptorProto{
+					{
+						Name:   proto.String("names"),
+						Number: proto.Int32(1),
+						Label:  descriptor.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+						Type:   descriptor.FieldDescriptorProto_TYPE_STRING.Enum(),
+					},
+				},
+			},
+		},
+		EnumType: []*descriptor.EnumDescriptorProto{
+			{
+				Name:  proto.String("Color"),
+				Value: []*descriptor.EnumValueDescriptorProto{{Name: proto.String("RED"), Number: proto.Int32(0)}},
+			},
+		},
+		Service: []*descriptor.ServiceDescriptorProto{
+			{
+				Name: proto.String("Things"),
+				Method: []*descriptor.MethodDescriptorProto{
+					{
+						Name:       proto.String("GetThing"),
+						InputType:  proto.String(".foo.Thing"),
+						OutputType: proto.String(".foo.Thing"),
+					},
+				},
+			},
+		},
+		SourceCodeInfo: &descriptor.SourceCodeInfo{
+			Location: []*descriptor.SourceCodeInfo_Location{
+				{Path: []int32{12}, LeadingDetachedComments: []string{" The foo API.\n"}},
+			},
+		},
+	}
+}
+
+func TestGenerateBuiltinTemplate(t *testing.T) {
+	request := newTestRequest(newBuiltinTestFile())
+	config := Config{
+		Operations: []OperationConfig{{Target: "foo.proto", Output: "foo.html"}},
+	}
+	response, err := Generate(request, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Error != nil {
+		t.Fatalf("unexpected error: %s", response.GetError())
+	}
+
+	got := response.File[0].GetContent()
+	for _, expected := range []string{
+		"<h1>foo</h1>",
+		"<p>The foo API.</p>",
+		`<h3 id="Thing">Thing</h3>`,
+		"<td><code>names</code></td>\n<td>repeated string</td>",
+		`<h3 id="Color">Color</h3>`,
+		"<td><code>RED</code></td>",
+		`<h3 id="Things">Things</h3>`,
+		`<td><code>GetThing</code></td>`,
+	} {
+		if !strings.Contains(got, expected) {
+			t.Fatalf("expected %q to contain %q", got, expected)
+		}
+	}
+}
+
+func TestGenerateUnknownBuiltinTemplate(t *testing.T) {
+	request := newTestRequest(newBuiltinTestFile())
+	config := Config{
+		BuiltinTemplate: "fancy",
+		Operations:      []OperationConfig{{Target: "foo.proto", Output: "foo.html"}},
+	}
+	_, err := Generate(request, config)
+	if err == nil || !strings.Contains(err.Error(), `unknown builtin template "fancy"`) {
+		t.Fatalf("expected an unknown builtin template error, got %v", err)
+	}
+}
+
+func TestGenerateBuiltinTemplateCustomDelims(t *testing.T) {
+	request := newTestRequest(newBuiltinTestFile())
+	config := Config{
+		Operations: []OperationConfig{
+			{Target: "foo.proto", Output: "foo.html", LeftDelim: "[[", RightDelim: "]]"},
+		},
+	}
+	response, err := Generate(request, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Error != nil {
+		t.Fatalf("unexpected error: %s", response.GetError())
+	}
+
+	got := response.File[0].GetContent()
+	if !strings.Contains(got, `<h3 id="Thing">Thing</h3>`) || strings.Contains(got, "{{") {
+		t.Fatalf("expected the builtin template to be executed, got %q", got)
+	}
+}
@@ -0,0 +1,129 @@
+package tmpl
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dnephin/proto-gen-html/util"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+const formatBundle = "llms"
+
+// genBundle returns a single markdown document for all the files being
+// generated, intended for ingestion by tools such as LLMs. The document starts
+// with a YAML front-matter block listing the files, packages, and services.
+// Every section has an explicit anchor which does not depend on the order of
+// the files, so links into the bundle remain stable.
+func (g *generator) genBundle(opConfig OperationConfig) (string, error) {
+	files := g.filesToGenerate()
+	funcs := &tmplFuncs{
+		outputFile:     opConfig.Output,
+		urlRoot:        g.urlRoot(opConfig),
+		protoFiles:     g.request.GetProtoFile(),
+		files:          files,
+		singleDocument: true,
+		locations:      g.locations,
+		config:         g.config,
+	}
+
+	buf := new(bytes.Buffer)
+	funcs.writeFrontMatter(buf, files)
+	for _, file := range files {
+		funcs.writeBundleFile(buf, file)
+	}
+	return buf.String(), nil
+}
+
+func (f *tmplFuncs) writeFrontMatter(buf *bytes.Buffer, files []*descriptor.FileDescriptorProto) {
+	var names, packages, services []string
+	seen := map[string]bool{}
+	for _, file := range files {
+		names = append(names, file.GetName())
+		if pkg := file.GetPackage(); pkg != "" && !seen[pkg] {
+			seen[pkg] = true
+			packages = append(packages, pkg)
+		}
+		for _, service := range f.services(file) {
+			services = append(services, bundleName(file, service.GetName()))
+		}
+	}
+
+	buf.WriteString("---\n")
+	writeYAMLList(buf, "files", names)
+	writeYAMLList(buf, "packages", packages)
+	writeYAMLList(buf, "services", services)
+	buf.WriteString("---\n")
+}
+
+func writeYAMLList(buf *bytes.Buffer, key string, values []string) {
+	if len(values) == 0 {
+		fmt.Fprintf(buf, "%s: []\n", key)
+		return
+	}
+	fmt.Fprintf(buf, "%s:\n", key)
+	for _, v := range values {
+		fmt.Fprintf(buf, "  - %s\n", strconv.Quote(v))
+	}
+}
+
+// fileAnchor returns the anchor of the section for the file in the bundle.
+func fileAnchor(file *descriptor.FileDescriptorProto) string {
+	return "file-" + slug(file.GetName())
+}
+
+// bundleName returns the fully-qualified name of a type without the leading
+// ".".
+func bundleName(file *descriptor.FileDescriptorProto, name string) string {
+	return strings.TrimPrefix(util.FullName(file, name), ".")
+}
+
+func (f *tmplFuncs) writeBundleFile(buf *bytes.Buffer, file *descriptor.FileDescriptorProto) {
+	fmt.Fprintf(buf, "\n<a id=\"%s\"></a>\n\n## %s\n\n", fileAnchor(file), file.GetName())
+	if pkg := file.GetPackage(); pkg != "" {
+		fmt.Fprintf(buf, "Package: `%s`\n\n", pkg)
+	}
+
+	for _, service := range f.services(file) {
+		f.writeBundleHeading(buf, file, "Service", service.GetName(), service)
+		for _, method := range f.methods(service) {
+			fmt.Fprintf(buf, "- `rpc %s(%s%s) returns (%s%s)`\n",
+				method.GetName(),
+				streamPrefix(method.GetClientStreaming()), strings.TrimPrefix(method.GetInputType(), "."),
+				streamPrefix(method.GetServerStreaming()), strings.TrimPrefix(method.GetOutputType(), "."))
+		}
+	}
+	for _, msg := range f.allMessages(file) {
+		f.writeBundleHeading(buf, file, "Message", msg.GetName(), msg)
+		for _, field := range f.fields(msg) {
+			typeName := f.mapType(field)
+			if typeName == "" {
+				typeName = fieldLabel(file, field) + fieldType(field)
+			}
+			fmt.Fprintf(buf, "- `%s %s = %d`\n", typeName, field.GetName(), field.GetNumber())
+		}
+	}
+	for _, enum := range f.allEnums(file) {
+		f.writeBundleHeading(buf, file, "Enum", enum.GetName(), enum)
+		for _, value := range f.enumValues(enum) {
+			fmt.Fprintf(buf, "- `%s = %d`\n", value.GetName(), value.GetNumber())
+		}
+	}
+}
+
+func (f *tmplFuncs) writeBundleHeading(buf *bytes.Buffer, file *descriptor.FileDescriptorProto, kind, name string, node interface{}) {
+	fullName := util.FullName(file, name)
+	fmt.Fprintf(buf, "\n<a id=\"%s\"></a>\n\n### %s %s\n\n", f.anchor(fullName, file), kind, bundleName(file, name))
+	if comment := strings.TrimSpace(f.location(node).GetLeadingComments()); comment != "" {
+		fmt.Fprintf(buf, "%s\n\n", comment)
+	}
+}
+
+func streamPrefix(streaming bool) string {
+	if streaming {
+		return "stream "
+	}
+	return ""
+}
@@ -0,0 +1,176 @@
+package tmpl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
+)
+
+func TestGenerateBundle(t *testing.T) {
+	foo := &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo/foo.proto"),
+		Package: proto.String("foo"),
+		MessageType: []*descriptor.DescriptorProto{
+			{Name: proto.String("Request")},
+			{Name: proto.String("Response")},
+		},
+		Service: []*descriptor.ServiceDescriptorProto{
+			{
+				Name: proto.String("Things"),
+				Method: []*descriptor.MethodDescriptorProto{
+					{
+						Name:            proto.String("Watch"),
+						InputType:       proto.String(".foo.Request"),
+						OutputType:      proto.String(".foo.Response"),
+						ServerStreaming: proto.Bool(true),
+					},
+				},
+			},
+		},
+		SourceCodeInfo: &descriptor.SourceCodeInfo{
+			Location: []*descriptor.SourceCodeInfo_Location{
+				{Path: []int32{4, 0}, LeadingComments: proto.String(" A request.\n")},
+			},
+		},
+	}
+	bar := &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo/bar.proto"),
+		Package: proto.String("foo"),
+		EnumType: []*descriptor.EnumDescriptorProto{
+			{
+				Name:  proto.String("Kind"),
+				Value: []*descriptor.EnumValueDescriptorProto{enumValue("KIND_UNKNOWN", 0)},
+			},
+		},
+	}
+	request := &plugin.CodeGeneratorRequest{
+		FileToGenerate: []string{"foo/foo.proto", "foo/bar.proto"},
+		ProtoFile:      []*descriptor.FileDescriptorProto{foo, bar},
+	}
+	config := Config{
+		Operations: []OperationConfig{{Format: "llms", Output: "api.md"}},
+	}
+	response, err := Generate(request, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Error != nil {
+		t.Fatalf("unexpected error: %s", response.GetError())
+	}
+	got := response.File[0].GetContent()
+
+	frontMatter := `---
+files:
+  - "foo/foo.proto"
+  - "foo/bar.proto"
+packages:
+  - "foo"
+services:
+  - "foo.Things"
+---
+`
+	if !strings.HasPrefix(got, frontMatter) {
+		t.Fatalf("expected front-matter %q, got %q", frontMatter, got)
+	}
+	for _, expected := range []string{
+		"<a id=\"file-foo-foo-proto\"></a>\n\n## foo/foo.proto\n",
+		"<a id=\"foo.Things\"></a>\n\n### Service foo.Things\n",
+		"- `rpc Watch(foo.Request) returns (stream foo.Response)`\n",
+		"<a id=\"foo.Request\"></a>\n\n### Message foo.Request\n\nA request.\n",
+		"<a id=\"file-foo-bar-proto\"></a>\n\n## foo/bar.proto\n",
+		"<a id=\"foo.Kind\"></a>\n\n### Enum foo.Kind\n",
+		"- `KIND_UNKNOWN = 0`\n",
+	} {
+		if !strings.Contains(got, expected) {
+			t.Errorf("expected bundle to contain %q, got %q", expected, got)
+		}
+	}
+}
+
+func TestGenerateBundleNestedTypesAndMaps(t *testing.T) {
+	file := &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptor.DescriptorProto{
+			{
+				Name: proto.String("Outer"),
+				Field: []*descriptor.FieldDescriptorProto{
+					{
+						Name:     proto.String("labels"),
+						Number:   proto.Int32(1),
+						Label:    descriptor.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+						Type:     descriptor.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+						TypeName: proto.String(".foo.Outer.LabelsEntry"),
+					},
+				},
+				NestedType: []*descriptor.DescriptorProto{
+					{Name: proto.String("Inner")},
+					{
+						Name: proto.String("LabelsEntry"),
+						Field: []*descriptor.FieldDescriptorProto{
+							{
+								Name:   proto.String("key"),
+								Number: proto.Int32(1),
+								Label:  descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+								Type:   descriptor.FieldDescriptorProto_TYPE_STRING.Enum(),
+							},
+							{
+								Name:     proto.String("value"),
+								Number:   proto.Int32(2),
+								Label:    descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+								Type:     descriptor.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+								TypeName: proto.String(".foo.Outer.Inner"),
+							},
+						},
+						Options: &descriptor.MessageOptions{MapEntry: proto.Bool(true)},
+					},
+				},
+				EnumType: []*descriptor.EnumDescriptorProto{
+					{
+						Name:  proto.String("Kind"),
+						Value: []*descriptor.EnumValueDescriptorProto{enumValue("KIND_UNKNOWN", 0)},
+					},
+				},
+			},
+		},
+		SourceCodeInfo: &descriptor.SourceCodeInfo{
+			Location: []*descriptor.SourceCodeInfo_Location{
+				{Path: []int32{4, 0, 3, 0}, LeadingComments: proto.String(" The inner message.\n")},
+				{Path: []int32{4, 0, 4, 0}, LeadingComments: proto.String(" The kind of the outer message.\n")},
+			},
+		},
+	}
+	request := &plugin.CodeGeneratorRequest{
+		FileToGenerate: []string{"foo.proto"},
+		ProtoFile:      []*descriptor.FileDescriptorProto{file},
+	}
+	config := Config{
+		Operations:     []OperationConfig{{Format: "llms", Output: "api.md"}},
+		HideMapEntries: true,
+	}
+	response, err := Generate(request, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Error != nil {
+		t.Fatalf("unexpected error: %s", response.GetError())
+	}
+	got := response.File[0].GetContent()
+
+	for _, expected := range []string{
+		"- `map<string, Inner> labels = 1`\n",
+		"### Message foo.Outer.Inner\n\nThe inner message.\n",
+		"### Enum foo.Outer.Kind\n\nThe kind of the outer message.\n",
+	} {
+		if !strings.Contains(got, expected) {
+			t.Errorf("expected bundle to contain %q, got %q", expected, got)
+		}
+	}
+	if strings.Contains(got, "LabelsEntry") {
+		t.Errorf("expected map entry to be hidden, got %q", got)
+	}
+}
@@ -0,0 +1,170 @@
+package tmpl
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/dnephin/proto-gen-html/util"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// allMessages returns all the messages in the file, including nested ones. See
+// util.AllMessages. The synthetic entry messages of map fields are excluded when
+// Config.HideMapEntries is set, and deprecated messages are excluded when
+// Config.HideDeprecated is set.
+func (f *tmplFuncs) allMessages(file *descriptor.FileDescriptorProto) []*descriptor.DescriptorProto {
+	return f.filterMessages(util.AllMessages(file))
+}
+
+// filterMessages removes the messages excluded by Config.HideMapEntries and
+// Config.HideDeprecated from all, and sorts them when Config.Canonical is set.
+func (f *tmplFuncs) filterMessages(all []*descriptor.DescriptorProto) []*descriptor.DescriptorProto {
+	all = append([]*descriptor.DescriptorProto{}, all...)
+	if f.config.HideMapEntries || f.config.HideDeprecated {
+		messages := all[:0]
+		for _, msg := range all {
+			if f.config.HideMapEntries && msg.GetOptions().GetMapEntry() {
+				continue
+			}
+			if f.config.HideDeprecated && isDeprecated(msg) {
+				continue
+			}
+			messages = append(messages, msg)
+		}
+		all = messages
+	}
+	if f.config.Canonical {
+		sort.SliceStable(all, func(i, j int) bool { return all[i].GetName() < all[j].GetName() })
+	}
+	return all
+}
+
+// allEnums returns all the enums in the file, including nested ones. See
+// util.AllEnums. Deprecated enums are excluded when Config.HideDeprecated is
+// set.
+func (f *tmplFuncs) allEnums(file *descriptor.FileDescriptorProto) []*descriptor.EnumDescriptorProto {
+	return f.filterEnums(util.AllEnums(file))
+}
+
+// filterEnums removes the enums excluded by Config.HideDeprecated from all, and
+// sorts them when Config.Canonical is set.
+func (f *tmplFuncs) filterEnums(all []*descriptor.EnumDescriptorProto) []*descriptor.EnumDescriptorProto {
+	all = append([]*descriptor.EnumDescriptorProto{}, all...)
+	if f.config.HideDeprecated {
+		enums := all[:0]
+		for _, enum := range all {
+			if !isDeprecated(enum) {
+				enums = append(enums, enum)
+			}
+		}
+		all = enums
+	}
+	if f.config.Canonical {
+		sort.SliceStable(all, func(i, j int) bool { return all[i].GetName() < all[j].GetName() })
+	}
+	return all
+}
+
+// fields returns the fields of the message which are at least as visible as
+// Config.MinVisibility, see visibleFields. They are sorted by number when
+// Config.Canonical is set.
+func (f *tmplFuncs) fields(msg *descriptor.DescriptorProto) []*descriptor.FieldDescriptorProto {
+	all := append([]*descriptor.FieldDescriptorProto{}, f.visibleFields(msg)...)
+	if f.config.Canonical {
+		sort.SliceStable(all, func(i, j int) bool { return all[i].GetNumber() < all[j].GetNumber() })
+	}
+	return all
+}
+
+// enumValues returns the values of the enum.
+func (f *tmplFuncs) enumValues(enum *descriptor.EnumDescriptorProto) []*descriptor.EnumValueDescriptorProto {
+	all := append([]*descriptor.EnumValueDescriptorProto{}, enum.GetValue()...)
+	if f.config.Canonical {
+		sort.SliceStable(all, func(i, j int) bool {
+			if all[i].GetNumber() != all[j].GetNumber() {
+				return all[i].GetNumber() < all[j].GetNumber()
+			}
+			return all[i].GetName() < all[j].GetName()
+		})
+	}
+	return all
+}
+
+// services returns the services of the file. Deprecated services are excluded
+// when Config.HideDeprecated is set.
+func (f *tmplFuncs) services(file *descriptor.FileDescriptorProto) []*descriptor.ServiceDescriptorProto {
+	var all []*descriptor.ServiceDescriptorProto
+	for _, service := range file.GetService() {
+		if !f.config.HideDeprecated || !isDeprecated(service) {
+			all = append(all, service)
+		}
+	}
+	if f.config.Canonical {
+		sort.SliceStable(all, func(i, j int) bool { return all[i].GetName() < all[j].GetName() })
+	}
+	return all
+}
+
+// methods returns the methods of the service. The input and output types of the
+// methods are fully-qualified, so they can be passed to typeURL, and the
+// methods can be passed to location for their comments.
+func (f *tmplFuncs) methods(service *descriptor.ServiceDescriptorProto) []*descriptor.MethodDescriptorProto {
+	all := append([]*descriptor.MethodDescriptorProto{}, service.GetMethod()...)
+	if f.config.Canonical {
+		sort.SliceStable(all, func(i, j int) bool { return all[i].GetName() < all[j].GetName() })
+	}
+	return all
+}
+
+// methodList returns all the methods of all services in the file. See
+// fileMethods.
+func (f *tmplFuncs) methodList(file *descriptor.FileDescriptorProto) []methodEntry {
+	all := f.fileMethods(file)
+	if f.config.Canonical {
+		sort.SliceStable(all, func(i, j int) bool {
+			if all[i].Service != all[j].Service {
+				return all[i].Service < all[j].Service
+			}
+			return all[i].Method.GetName() < all[j].Method.GetName()
+		})
+	}
+	return all
+}
+
+// serviceNav returns an entry for each service in the file. See
+// fileServiceNav.
+func (f *tmplFuncs) serviceNav(file *descriptor.FileDescriptorProto) []serviceNavEntry {
+	all := f.fileServiceNav(file)
+	if f.config.Canonical {
+		sort.SliceStable(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+		for _, entry := range all {
+			methods := entry.Methods
+			sort.SliceStable(methods, func(i, j int) bool {
+				return methods[i].Method.GetName() < methods[j].Method.GetName()
+			})
+		}
+	}
+	return all
+}
+
+// normalizeWhitespace removes trailing whitespace from each line, collapses
+// consecutive blank lines, and ends the content with a single newline.
+func normalizeWhitespace(content string) string {
+	var (
+		lines []string
+		blank bool
+	)
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			if blank || len(lines) == 0 {
+				continue
+			}
+			blank = true
+		} else {
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n") + "\n"
+}
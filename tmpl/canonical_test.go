@@ -0,0 +1,137 @@
+package tmpl
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func newCanonicalFile(reverse bool) *descriptor.FileDescriptorProto {
+	field := func(name string, number int32) *descriptor.FieldDescriptorProto {
+		return &descriptor.FieldDescriptorProto{Name: proto.String(name), Number: proto.Int32(number)}
+	}
+	messages := []*descriptor.DescriptorProto{
+		{
+			Name:  proto.String("Alpha"),
+			Field: []*descriptor.FieldDescriptorProto{field("one", 1), field("two", 2)},
+		},
+		{Name: proto.String("Beta")},
+	}
+	values := []*descriptor.EnumValueDescriptorProto{enumValue("A", 0), enumValue("B", 1)}
+	services := []*descriptor.ServiceDescriptorProto{
+		{Name: proto.String("First")},
+		{Name: proto.String("Second")},
+	}
+	if reverse {
+		messages[0], messages[1] = messages[1], messages[0]
+		fields := messages[1].Field
+		fields[0], fields[1] = fields[1], fields[0]
+		values[0], values[1] = values[1], values[0]
+		services[0], services[1] = services[1], services[0]
+	}
+	return &descriptor.FileDescriptorProto{
+		Name:        proto.String("foo.proto"),
+		Package:     proto.String("foo"),
+		MessageType: messages,
+		EnumType: []*descriptor.EnumDescriptorProto{
+			{Name: proto.String("Kind"), Value: values},
+		},
+		Service: services,
+	}
+}
+
+func generateCanonical(t *testing.T, file *descriptor.FileDescriptorProto) string {
+	config := Config{
+		TemplateRoot: testdataRoot(t),
+		Canonical:    true,
+		Operations: []OperationConfig{
+			{Template: "canonical.html", Target: "foo.proto", Output: "foo.html"},
+		},
+	}
+	response, err := Generate(newTestRequest(file), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Error != nil {
+		t.Fatalf("unexpected error: %s", response.GetError())
+	}
+	return response.File[0].GetContent()
+}
+
+func TestCanonicalOutputIsStable(t *testing.T) {
+	ordered := generateCanonical(t, newCanonicalFile(false))
+	reversed := generateCanonical(t, newCanonicalFile(true))
+	if ordered != reversed {
+		t.Fatalf("output changed when inputs were reordered:\n%s\n---\n%s", ordered, reversed)
+	}
+
+	expected := `message Alpha
+  1 one
+  2 two
+
+message Beta
+
+enum Kind
+  A = 0
+  B = 1
+
+service First
+service Second
+`
+	if ordered != expected {
+		t.Fatalf("got:\n%s\nexpected:\n%s", ordered, expected)
+	}
+}
+
+func TestMethodsLinkAndComments(t *testing.T) {
+	file := &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+		MessageType: []*descriptor.DescriptorProto{
+			{Name: proto.String("Request")},
+			{Name: proto.String("Response")},
+		},
+		Service: []*descriptor.ServiceDescriptorProto{
+			{
+				Name: proto.String("Things"),
+				Method: []*descriptor.MethodDescriptorProto{
+					{
+						Name:            proto.String("Watch"),
+						InputType:       proto.String(".foo.Request"),
+						OutputType:      proto.String(".foo.Response"),
+						ServerStreaming: proto.Bool(true),
+					},
+				},
+			},
+		},
+		SourceCodeInfo: &descriptor.SourceCodeInfo{
+			Location: []*descriptor.SourceCodeInfo_Location{
+				{Path: []int32{6, 0, 2, 0}, LeadingComments: proto.String(" Watch things.\n")},
+			},
+		},
+	}
+	f := &tmplFuncs{
+		protoFileDescriptor: file,
+		outputFile:          "foo.html",
+		protoFiles:          []*descriptor.FileDescriptorProto{file},
+	}
+
+	methods := f.methods(f.services(file)[0])
+	if len(methods) != 1 {
+		t.Fatalf("expected 1 method, got %d", len(methods))
+	}
+	method := methods[0]
+	if got := f.typeURL(method.GetInputType()); got != "foo.html#Request" {
+		t.Fatalf("got input URL %q", got)
+	}
+	if got := f.typeURL(method.GetOutputType()); got != "foo.html#Response" {
+		t.Fatalf("got output URL %q", got)
+	}
+	if method.GetClientStreaming() || !method.GetServerStreaming() {
+		t.Fatalf("unexpected streaming flags %+v", methodStreaming(method))
+	}
+	if got := f.location(method).GetLeadingComments(); got != " Watch things.\n" {
+		t.Fatalf("got comment %q", got)
+	}
+}
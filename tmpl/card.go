@@ -0,0 +1,77 @@
+package tmpl
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+
+	"github.com/dnephin/proto-gen-html/util"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// Columns of a message card, see MessageCardOptions.
+const (
+	cardColumnName    = "name"
+	cardColumnFields  = "fields"
+	cardColumnSummary = "summary"
+)
+
+var defaultCardColumns = []string{cardColumnName, cardColumnFields, cardColumnSummary}
+
+// messageCard returns a compact summary of a message of the target file, for
+// rendering in a grid. The card contains the columns configured by
+// Config.MessageCard, each in an element with a "message-card-<column>" class.
+func (f *tmplFuncs) messageCard(msg *descriptor.DescriptorProto) template.HTML {
+	columns := f.config.MessageCard.Columns
+	if len(columns) == 0 {
+		columns = defaultCardColumns
+	}
+
+	buf := new(bytes.Buffer)
+	buf.WriteString(`<div class="message-card">`)
+	for _, column := range columns {
+		var content string
+		switch column {
+		case cardColumnName:
+			name := template.HTMLEscapeString(msg.GetName())
+			// The message may be declared in another file than the one being
+			// generated, or be a copy of a nested message from allMessages.
+			fullName := f.messageFullName(msg)
+			if fullName == "" {
+				fullName = util.FullName(f.protoFileDescriptor, msg.GetName())
+			}
+			url := f.typeURL(fullName)
+			content = fmt.Sprintf(`<a href="%s">%s</a>`, template.HTMLEscapeString(url), name)
+		case cardColumnFields:
+			content = pluralize(len(f.fields(msg)), "field", "fields")
+		case cardColumnSummary:
+			content = template.HTMLEscapeString(commentSummary(f.location(msg).GetLeadingComments()))
+		default:
+			continue
+		}
+		fmt.Fprintf(buf, `<div class="message-card-%s">%s</div>`, column, content)
+	}
+	buf.WriteString(`</div>`)
+	return template.HTML(buf.String())
+}
+
+// commentSummary returns the first paragraph of the comment, joined into a
+// single line.
+func commentSummary(comment string) string {
+	var words []string
+	for _, line := range strings.Split(strings.TrimSpace(comment), "\n") {
+		if strings.TrimSpace(line) == "" {
+			break
+		}
+		words = append(words, strings.Fields(line)...)
+	}
+	return strings.Join(words, " ")
+}
+
+func pluralize(n int, singular, plural string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, singular)
+	}
+	return fmt.Sprintf("%d %s", n, plural)
+}
@@ -0,0 +1,99 @@
+package tmpl
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func newCardFile() *descriptor.FileDescriptorProto {
+	return &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+		MessageType: []*descriptor.DescriptorProto{
+			{
+				Name: proto.String("Foo"),
+				Field: []*descriptor.FieldDescriptorProto{
+					{Name: proto.String("a"), Number: proto.Int32(1)},
+					{Name: proto.String("b"), Number: proto.Int32(2)},
+				},
+			},
+		},
+		SourceCodeInfo: &descriptor.SourceCodeInfo{
+			Location: []*descriptor.SourceCodeInfo_Location{
+				{
+					Path:            []int32{4, 0},
+					LeadingComments: proto.String(" Foo is a thing\n which is <small>.\n\n More details.\n"),
+				},
+			},
+		},
+	}
+}
+
+func TestMessageCard(t *testing.T) {
+	f := newTestFuncs(Config{}, newCardFile())
+
+	got := string(f.messageCard(f.protoFileDescriptor.MessageType[0]))
+	expected := `<div class="message-card">` +
+		`<div class="message-card-name"><a href="foo.html#Foo">Foo</a></div>` +
+		`<div class="message-card-fields">2 fields</div>` +
+		`<div class="message-card-summary">Foo is a thing which is &lt;small&gt;.</div>` +
+		`</div>`
+	if got != expected {
+		t.Fatalf("got %q expected %q", got, expected)
+	}
+}
+
+func TestMessageCardColumns(t *testing.T) {
+	f := newTestFuncs(Config{MessageCard: MessageCardOptions{Columns: []string{"fields", "name"}}}, newCardFile())
+
+	got := string(f.messageCard(f.protoFileDescriptor.MessageType[0]))
+	expected := `<div class="message-card">` +
+		`<div class="message-card-fields">2 fields</div>` +
+		`<div class="message-card-name"><a href="foo.html#Foo">Foo</a></div>` +
+		`</div>`
+	if got != expected {
+		t.Fatalf("got %q expected %q", got, expected)
+	}
+}
+
+func TestMessageCardNestedInIndex(t *testing.T) {
+	file := &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+		MessageType: []*descriptor.DescriptorProto{
+			{
+				Name: proto.String("Foo"),
+				NestedType: []*descriptor.DescriptorProto{
+					{
+						Name:  proto.String("Inner"),
+						Field: []*descriptor.FieldDescriptorProto{{Name: proto.String("a"), Number: proto.Int32(1)}},
+					},
+				},
+			},
+		},
+		SourceCodeInfo: &descriptor.SourceCodeInfo{
+			Location: []*descriptor.SourceCodeInfo_Location{
+				{Path: []int32{4, 0, 3, 0}, LeadingComments: proto.String(" Inner is nested.\n")},
+			},
+		},
+	}
+	// Index pages are not generated for a single file, so they have no
+	// protoFileDescriptor.
+	f := &tmplFuncs{
+		outputFile: "index.html",
+		protoFiles: []*descriptor.FileDescriptorProto{file},
+		files:      []*descriptor.FileDescriptorProto{file},
+	}
+
+	got := string(f.messageCard(f.allMessages(file)[1]))
+	expected := `<div class="message-card">` +
+		`<div class="message-card-name"><a href="foo.html#Foo.Inner">Foo.Inner</a></div>` +
+		`<div class="message-card-fields">1 field</div>` +
+		`<div class="message-card-summary">Inner is nested.</div>` +
+		`</div>`
+	if got != expected {
+		t.Fatalf("got %q expected %q", got, expected)
+	}
+}
@@ -0,0 +1,122 @@
+package tmpl
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/dnephin/proto-gen-html/util"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/pkg/errors"
+)
+
+// clientExample returns a short snippet which calls the method, in the
+// language lang. Supported languages are "curl", which calls the first HTTP
+// binding of the method, and "go", which calls the method with the generated
+// gRPC client. Methods without an HTTP binding have no curl example.
+func (f *tmplFuncs) clientExample(method *descriptor.MethodDescriptorProto, lang string) (string, error) {
+	switch lang {
+	case "curl":
+		return f.curlExample(method), nil
+	case "go":
+		return f.goClientExample(method), nil
+	default:
+		return "", errors.Errorf("unknown client example language %q", lang)
+	}
+}
+
+func (f *tmplFuncs) curlExample(method *descriptor.MethodDescriptorProto) string {
+	rules := methodHTTPRules(method)
+	if len(rules) == 0 {
+		return ""
+	}
+	rule := rules[0]
+
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "curl -X %s \"$BASE_URL%s\"", rule.Method, rule.Path)
+	if body := f.requestBody(method, rule); body != "" {
+		buf.WriteString(" \\\n  -H \"Content-Type: application/json\" \\\n")
+		fmt.Fprintf(buf, "  -d '%s'", body)
+	}
+	buf.WriteString("\n")
+	return buf.String()
+}
+
+// requestBody returns an example of the HTTP request body of the rule, or an
+// empty string if the rule has no body.
+func (f *tmplFuncs) requestBody(method *descriptor.MethodDescriptorProto, rule httpRule) string {
+	resolver := util.NewResolver(f.protoFiles)
+	switch rule.Body {
+	case "":
+		return ""
+	case "*":
+		node, _ := resolver.Resolve(method.GetInputType(), nil)
+		if msg, ok := node.(*descriptor.DescriptorProto); ok {
+			return f.jsonExample(msg)
+		}
+		return "{}"
+	default:
+		field := f.resolveFieldPath(method.GetInputType(), rule.Body)
+		if field == nil {
+			return "{}"
+		}
+		node, _ := resolver.Resolve(field.GetTypeName(), nil)
+		if msg, ok := node.(*descriptor.DescriptorProto); ok {
+			return f.jsonExample(msg)
+		}
+		return f.defaultValue(field)
+	}
+}
+
+func (f *tmplFuncs) goClientExample(method *descriptor.MethodDescriptorProto) string {
+	file, service := f.protoFileDescriptor, (*descriptor.ServiceDescriptorProto)(nil)
+	for _, v := range f.protoFiles {
+		if service = methodService(v, method); service != nil {
+			file = v
+			break
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	pkg := goPackageName(file)
+	if service != nil {
+		fmt.Fprintf(buf, "client := %s.New%sClient(conn)\n", pkg, service.GetName())
+	}
+	if method.GetClientStreaming() {
+		fmt.Fprintf(buf, "stream, err := client.%s(ctx)\n", method.GetName())
+		return buf.String()
+	}
+
+	result := "resp"
+	if method.GetServerStreaming() {
+		result = "stream"
+	}
+	fmt.Fprintf(buf, "%s, err := client.%s(ctx, &%s{})\n",
+		result, method.GetName(), f.goTypeName(method.GetInputType()))
+	return buf.String()
+}
+
+// goTypeName returns the name of the Go type generated for the message with
+// the fully-qualified symbolPath, qualified by its Go package name.
+func (f *tmplFuncs) goTypeName(symbolPath string) string {
+	_, file := util.NewResolver(f.protoFiles).Resolve(symbolPath, nil)
+	if file == nil {
+		return strings.Replace(strings.TrimPrefix(symbolPath, "."), ".", "_", -1)
+	}
+	name := strings.TrimPrefix(symbolPath, util.FullName(file, ""))
+	return goPackageName(file) + "." + strings.Replace(name, ".", "_", -1)
+}
+
+// goPackageName returns the name of the Go package generated for the file,
+// from the go_package option, or from the proto package if the option is not
+// set.
+func goPackageName(file *descriptor.FileDescriptorProto) string {
+	if alias := goPackageAlias(file); alias != "" {
+		return alias
+	}
+	if importPath := goImportPath(file); importPath != "" {
+		return path.Base(importPath)
+	}
+	return strings.Replace(util.PackageName(file), ".", "_", -1)
+}
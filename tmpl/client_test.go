@@ -0,0 +1,68 @@
+package tmpl
+
+import (
+	"testing"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+)
+
+func TestClientExampleCurl(t *testing.T) {
+	method := newHTTPMethod(t, &annotations.HttpRule{
+		Pattern: &annotations.HttpRule_Get{Get: "/v1/items/{id}"},
+	})
+	f := newTestFuncs(Config{}, newHTTPFile(method))
+
+	got, err := f.clientExample(method, "curl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "curl -X GET \"$BASE_URL/v1/items/{id}\"\n"
+	if got != expected {
+		t.Fatalf("got %q expected %q", got, expected)
+	}
+}
+
+func TestClientExampleCurlWithBody(t *testing.T) {
+	method := newHTTPMethod(t, &annotations.HttpRule{
+		Pattern: &annotations.HttpRule_Post{Post: "/v1/items"},
+		Body:    "*",
+	})
+	f := newTestFuncs(Config{}, newHTTPFile(method))
+
+	got, err := f.clientExample(method, "curl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "curl -X POST \"$BASE_URL/v1/items\" \\\n" +
+		"  -H \"Content-Type: application/json\" \\\n" +
+		"  -d '{\n  \"id\": \"\"\n}'\n"
+	if got != expected {
+		t.Fatalf("got %q expected %q", got, expected)
+	}
+}
+
+func TestClientExampleGo(t *testing.T) {
+	method := newHTTPMethod(t, &annotations.HttpRule{
+		Pattern: &annotations.HttpRule_Get{Get: "/v1/items/{id}"},
+	})
+	f := newTestFuncs(Config{}, newHTTPFile(method))
+
+	got, err := f.clientExample(method, "go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "client := foo.NewItemsClient(conn)\n" +
+		"resp, err := client.GetItem(ctx, &foo.GetItemRequest{})\n"
+	if got != expected {
+		t.Fatalf("got %q expected %q", got, expected)
+	}
+}
+
+func TestClientExampleUnknownLanguage(t *testing.T) {
+	method := newHTTPMethod(t, &annotations.HttpRule{})
+	f := newTestFuncs(Config{}, newHTTPFile(method))
+
+	if _, err := f.clientExample(method, "cobol"); err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -0,0 +1,127 @@
+package tmpl
+
+import (
+	"strings"
+
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// comments splits the comment into segments, one for each paragraph. The lines
+// of a paragraph are joined with a space, and paragraphs are separated by
+// blank lines. Fenced ("```") and indented code blocks are segments of their
+// own, with their lines and indentation preserved, so that markdown renders
+// them as code.
+func comments(comment string) []string {
+	lines := trimCommentIndent(strings.Split(comment, "\n"))
+
+	var segments []string
+	var words []string
+	flush := func() {
+		if len(words) > 0 {
+			segments = append(segments, strings.Join(words, " "))
+			words = nil
+		}
+	}
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.TrimSpace(line) == "":
+			flush()
+		case strings.HasPrefix(strings.TrimSpace(line), "```"):
+			flush()
+			end := i + 1
+			for end < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[end]), "```") {
+				end++
+			}
+			if end == len(lines) {
+				end-- // an unterminated fence continues to the end of the comment
+			}
+			segments = append(segments, strings.Join(lines[i:end+1], "\n"))
+			i = end
+		case len(words) == 0 && isIndentedCode(line):
+			end := i + 1
+			for end < len(lines) && (isIndentedCode(lines[end]) || strings.TrimSpace(lines[end]) == "") {
+				end++
+			}
+			for strings.TrimSpace(lines[end-1]) == "" {
+				end--
+			}
+			segments = append(segments, strings.Join(lines[i:end], "\n"))
+			i = end - 1
+		default:
+			words = append(words, strings.Fields(line)...)
+		}
+	}
+	flush()
+	return segments
+}
+
+// trimCommentIndent removes the space which follows "//" in proto comments from
+// each of the lines, if every line which is not blank starts with a space.
+func trimCommentIndent(lines []string) []string {
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" && !strings.HasPrefix(line, " ") {
+			return lines
+		}
+	}
+	trimmed := make([]string, len(lines))
+	for i, line := range lines {
+		trimmed[i] = strings.TrimPrefix(line, " ")
+	}
+	return trimmed
+}
+
+// isIndentedCode returns true if the line is part of a markdown indented code
+// block.
+func isIndentedCode(line string) bool {
+	return strings.TrimSpace(line) != "" && (strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t"))
+}
+
+// leadingComments returns the segments, see comments, of the comment above the
+// declaration of the element.
+func (f *tmplFuncs) leadingComments(x interface{}) []string {
+	return comments(f.location(x).GetLeadingComments())
+}
+
+// trailingComments returns the segments, see comments, of the comment after
+// the declaration of the element, e.g. the comment at the end of the line of a
+// field.
+func (f *tmplFuncs) trailingComments(x interface{}) []string {
+	return comments(f.location(x).GetTrailingComments())
+}
+
+// detachedComments returns the segments, see comments, of the comments above
+// the declaration of the element which are separated from it by a blank line.
+// The segments of each comment follow those of the previous comment.
+func (f *tmplFuncs) detachedComments(x interface{}) []string {
+	var segments []string
+	for _, comment := range f.location(x).GetLeadingDetachedComments() {
+		segments = append(segments, comments(comment)...)
+	}
+	return segments
+}
+
+// fileComments returns the segments, see comments, of the comments of the
+// file, which are the detached and leading comments of the syntax and package
+// statements, in the order of the statements. An overview at the top of a
+// file is detached from the statement which follows it.
+func fileComments(file *descriptor.FileDescriptorProto) []string {
+	var segments []string
+	for _, loc := range file.GetSourceCodeInfo().GetLocation() {
+		if len(loc.Path) != 1 || (loc.Path[0] != fileSyntaxPath && loc.Path[0] != filePackagePath) {
+			continue
+		}
+		for _, comment := range loc.GetLeadingDetachedComments() {
+			segments = append(segments, comments(comment)...)
+		}
+		segments = append(segments, comments(loc.GetLeadingComments())...)
+	}
+	return segments
+}
+
+// The field numbers of the syntax and package of FileDescriptorProto, which
+// are the paths of their source code info locations.
+const (
+	filePackagePath = 2
+	fileSyntaxPath  = 12
+)
@@ -0,0 +1,121 @@
+package tmpl
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestComments(t *testing.T) {
+	comment := " The first paragraph\n continues here.\n\n The second paragraph.\n\n\n"
+	expected := []string{"The first paragraph continues here.", "The second paragraph."}
+	if got := comments(comment); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %q expected %q", got, expected)
+	}
+	if got := comments(""); got != nil {
+		t.Fatalf("expected no segments, got %q", got)
+	}
+}
+
+func TestLeadingTrailingAndDetachedComments(t *testing.T) {
+	field := &descriptor.FieldDescriptorProto{Name: proto.String("count")}
+	file := &descriptor.FileDescriptorProto{
+		Name: proto.String("foo.proto"),
+		MessageType: []*descriptor.DescriptorProto{{
+			Name:  proto.String("Thing"),
+			Field: []*descriptor.FieldDescriptorProto{field},
+		}},
+		SourceCodeInfo: &descriptor.SourceCodeInfo{
+			Location: []*descriptor.SourceCodeInfo_Location{{
+				Path:             []int32{4, 0, 2, 0},
+				LeadingComments:  proto.String(" The number of things.\n"),
+				TrailingComments: proto.String(" inline\n"),
+				LeadingDetachedComments: []string{
+					" TODO: rename.\n",
+					" Section one.\n\n More of it.\n",
+				},
+			}},
+		},
+	}
+	f := &tmplFuncs{protoFileDescriptor: file}
+
+	if got, expected := f.leadingComments(field), []string{"The number of things."}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("leading: got %q expected %q", got, expected)
+	}
+	if got, expected := f.trailingComments(field), []string{"inline"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("trailing: got %q expected %q", got, expected)
+	}
+	expected := []string{"TODO: rename.", "Section one.", "More of it."}
+	if got := f.detachedComments(field); !reflect.DeepEqual(got, expected) {
+		t.Errorf("detached: got %q expected %q", got, expected)
+	}
+}
+
+func TestCommentsWithFencedCode(t *testing.T) {
+	comment := " Lists the things.\n wrapped here.\n\n ```go\n for _, thing := range things {\n\n     fmt.Println(thing)\n }\n ```\n Returns an error\n if the list fails.\n"
+	expected := []string{
+		"Lists the things. wrapped here.",
+		"```go\nfor _, thing := range things {\n\n    fmt.Println(thing)\n}\n```",
+		"Returns an error if the list fails.",
+	}
+	got := comments(comment)
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %q expected %q", got, expected)
+	}
+
+	f := &tmplFuncs{}
+	html := string(f.markdown(got[1]))
+	if !strings.Contains(html, "<pre><code>for _, thing := range things {\n\n    fmt.Println(thing)\n}\n</code></pre>") {
+		t.Fatalf("expected a code block, got %q", html)
+	}
+}
+
+func TestCommentsWithIndentedCode(t *testing.T) {
+	comment := " For example:\n\n     curl $URL\n       -d '{}'\n\n Then wait.\n"
+	expected := []string{
+		"For example:",
+		"    curl $URL\n      -d '{}'",
+		"Then wait.",
+	}
+	if got := comments(comment); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %q expected %q", got, expected)
+	}
+}
+
+func TestCommentsUnterminatedFence(t *testing.T) {
+	expected := []string{"Start.", "```\ncode"}
+	if got := comments("Start.\n```\ncode"); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %q expected %q", got, expected)
+	}
+}
+
+func TestFileComments(t *testing.T) {
+	file := &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+		SourceCodeInfo: &descriptor.SourceCodeInfo{
+			Location: []*descriptor.SourceCodeInfo_Location{
+				{Path: []int32{}},
+				{
+					Path:                    []int32{12},
+					LeadingDetachedComments: []string{" The foo API.\n\n It does foo.\n"},
+				},
+				{
+					Path:            []int32{2},
+					LeadingComments: proto.String(" Package foo.\n"),
+				},
+				{
+					Path:            []int32{4, 0},
+					LeadingComments: proto.String(" A message.\n"),
+				},
+			},
+		},
+	}
+	expected := []string{"The foo API.", "It does foo.", "Package foo."}
+	if got := fileComments(file); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %q expected %q", got, expected)
+	}
+}
@@ -1,22 +1,242 @@
 package tmpl
 
+import "html/template"
+
 // OperationConfig for rendering an html template from proto source
 type OperationConfig struct {
 	// Template is the path of the template file to use for generating the
-	// target.
-	Template string
+	// target. When empty the builtin template selected by
+	// Config.BuiltinTemplate is used.
+	Template string `json:"template" yaml:"template"`
 
 	// Target is the target proto file for generation. It must match one of the
-	// input proto files, or else the template will not be executed.
-	Target string
+	// input proto files, or else the template will not be executed. It may
+	// also be a glob pattern, e.g. "api/**/*.proto", in which case the
+	// operation is executed for each matching proto file.
+	Target string `json:"target" yaml:"target"`
 
 	// Output is the output file to write the executed template contents to.
-	Output string
+	// When Target is a pattern, Output is a template executed for each
+	// matching file, and {{.Name}} is the name of the file without its
+	// extension, e.g. "docs/{{.Name}}.html".
+	Output string `json:"output" yaml:"output"`
+
+	// Mode selects what the template documents. When empty the template
+	// documents the Target file. When "single" the template documents all the
+	// files being generated in one page, and links to their types are links
+	// within the page. When "index" the template is an index of all the files
+	// being generated, which links to the pages of the other operations, listed
+	// in .Outputs.
+	Mode string `json:"mode" yaml:"mode"`
+
+	// Fallback is the path of a template which is executed when Template fails
+	// to render, so that a page is still produced. The error from Template is
+	// available to the fallback as .Error.
+	Fallback string `json:"fallback" yaml:"fallback"`
+
+	// Wrap is the path of a template which transforms the rendered output of
+	// the operation into the final output, for example to embed it in a JSON
+	// document. The rendered output is available to the template as .Body.
+	Wrap string `json:"wrap" yaml:"wrap"`
+
+	// LeftDelim and RightDelim are the action delimiters of the templates of
+	// the operation, for templates which use "{{" and "}}" for other
+	// purposes, such as Vue or Angular templates. An empty delimiter uses the
+	// default, "{{" or "}}".
+	LeftDelim  string `json:"leftDelim" yaml:"leftDelim"`
+	RightDelim string `json:"rightDelim" yaml:"rightDelim"`
+
+	// Format selects a built-in generator instead of executing Template. The
+	// supported values are "manifest", which writes a JSON Manifest of the
+	// files being generated, "llms", which writes all the files being
+	// generated as a single markdown document with a front-matter header,
+	// "grpcref", which lists the gRPC path of every method, one per line, and
+	// "search", which writes a JSON array of a SearchEntry for every symbol.
+	// When empty the template is executed.
+	Format string `json:"format" yaml:"format"`
+
+	// URLRoot overrides Config.URLRoot for the links generated by the
+	// operation to types in the package of its Target, for packages which are
+	// served under a different path. Links to types in other packages use
+	// Config.URLRoot. Operations without a Target use it for all links.
+	URLRoot string `json:"urlRoot" yaml:"urlRoot"`
 }
 
 // Config for the plugin
 type Config struct {
-	TemplateRoot string
-	URLRoot      string
-	Operations   []OperationConfig
+	TemplateRoot string            `json:"templateRoot" yaml:"templateRoot"`
+	URLRoot      string            `json:"urlRoot" yaml:"urlRoot"`
+	Operations   []OperationConfig `json:"operations" yaml:"operations"`
+
+	// AutolinkTypes enables linking of type names mentioned in comments which
+	// are rendered with the markdown function.
+	AutolinkTypes bool `json:"autolinkTypes" yaml:"autolinkTypes"`
+
+	// FieldNameStyle selects how the fieldName function displays field names.
+	// Valid values are "proto" (the default, lower_snake_case), "json" (the
+	// JSON name of the field), and "original" (the name as declared).
+	FieldNameStyle string `json:"fieldNameStyle" yaml:"fieldNameStyle"`
+
+	// Int64AsString renders 64-bit integers as JSON strings in the values
+	// returned by jsonExample and defaultValue, matching the protobuf JSON
+	// mapping. It defaults to true.
+	Int64AsString *bool `json:"int64AsString" yaml:"int64AsString"`
+
+	// Preamble is text, such as a "DO NOT EDIT" notice, which is added as a
+	// comment to the start of every output file, after the XML declaration
+	// and doctype if the file starts with them. The comment syntax is chosen
+	// by the extension of the output file, and files without a known comment
+	// syntax (e.g. JSON) are written without the preamble.
+	Preamble string `json:"preamble" yaml:"preamble"`
+
+	// EnumPalette is the list of colors used by enumValueColor. When empty a
+	// default palette is used.
+	EnumPalette []string `json:"enumPalette" yaml:"enumPalette"`
+
+	// VisibilityOption is the field number of a custom enum field option which
+	// sets the visibility of a field, e.g. "(visibility) = INTERNAL".
+	VisibilityOption int32 `json:"visibilityOption" yaml:"visibilityOption"`
+
+	// VisibilityEnum is the fully-qualified name of the enum type of the
+	// VisibilityOption. Its values must be ordered from the most visible to the
+	// least visible, e.g. PUBLIC, INTERNAL, PRIVATE.
+	VisibilityEnum string `json:"visibilityEnum" yaml:"visibilityEnum"`
+
+	// MinVisibility is the name of the least visible value of VisibilityEnum
+	// which is rendered by visibleFields. When empty all fields are rendered.
+	MinVisibility string `json:"minVisibility" yaml:"minVisibility"`
+
+	// Canonical sorts the collections returned by template functions, so that
+	// reordering declarations in the proto files does not change the output.
+	// Messages, enums, services, and methods are sorted by name, and fields
+	// and enum values by number. Whitespace in the output is also normalized.
+	Canonical bool `json:"canonical" yaml:"canonical"`
+
+	// PackageOverview maps package names to markdown files, relative to the
+	// TemplateRoot, which contain an overview of the package. The overview is
+	// rendered by the packageOverview function.
+	PackageOverview map[string]string `json:"packageOverview" yaml:"packageOverview"`
+
+	// SinceOption is the field number of a custom string option which sets
+	// the version an element was added in, e.g. "(since) = \"1.3\"". It is
+	// read by the since function when the comments of the element do not
+	// have a "@since" tag.
+	SinceOption int32 `json:"sinceOption" yaml:"sinceOption"`
+
+	// Markdown configures the markdown function.
+	Markdown MarkdownOptions `json:"markdown" yaml:"markdown"`
+
+	// Checksums adds a checksums.txt file to the output, which lists the
+	// SHA-256 of every other output file in the format of sha256sum.
+	Checksums bool `json:"checksums" yaml:"checksums"`
+
+	// DescriptionMaxChars is the maximum number of characters of a field
+	// description rendered in a row by fieldRow and fieldTable. Longer
+	// descriptions are truncated and followed by a link to the full text. When
+	// zero descriptions are not truncated.
+	DescriptionMaxChars int `json:"descriptionMaxChars" yaml:"descriptionMaxChars"`
+
+	// ScalarDisplayNames maps the keywords of scalar types, e.g. "int32", to
+	// the names displayed by the fieldType function, e.g. "whole number".
+	// Scalar types which are not in the map are displayed by their keyword.
+	ScalarDisplayNames map[string]string `json:"scalarDisplayNames" yaml:"scalarDisplayNames"`
+
+	// SkipEmpty skips writing the output of operations which is empty or only
+	// whitespace.
+	SkipEmpty bool `json:"skipEmpty" yaml:"skipEmpty"`
+
+	// FailOnNoOutput returns an error from generation when no files are
+	// generated, for example because every output was skipped by SkipEmpty.
+	FailOnNoOutput bool `json:"failOnNoOutput" yaml:"failOnNoOutput"`
+
+	// HideMapEntries excludes the synthetic entry messages which protoc
+	// creates for map fields from allMessages. Map fields can be rendered with
+	// mapType or fieldTypeLink instead.
+	HideMapEntries bool `json:"hideMapEntries" yaml:"hideMapEntries"`
+
+	// ExternalTypeURLs maps package names to the URL of their documentation,
+	// which is used by typeURL for types from those packages which are not
+	// being generated. In the URL, "{name}" is replaced by the name of the
+	// type relative to the package, "{lowerName}" by the same name in lower
+	// case, and "{fullName}" by the fully-qualified name of the type. The
+	// google.protobuf, google.api, and google.type packages link to their
+	// upstream reference by default, which can be changed, or disabled with
+	// an empty URL.
+	ExternalTypeURLs map[string]string `json:"externalTypeURLs" yaml:"externalTypeURLs"`
+
+	// CommentHeadingAnchors adds an id to the h2 to h6 headings rendered by
+	// the markdown function, so that sections of long comments can be linked
+	// to. The ids are prefixed with the symbol passed to markdown, e.g.
+	// {{markdown $comment "foo.Thing"}}, to keep them unique within a page.
+	CommentHeadingAnchors bool `json:"commentHeadingAnchors" yaml:"commentHeadingAnchors"`
+
+	// Partials is a list of glob patterns, relative to TemplateRoot, of
+	// templates which are parsed with the template of every operation, so that
+	// the templates they define can be used with {{template "name"}}.
+	Partials []string `json:"partials" yaml:"partials"`
+
+	// BuiltinTemplate is the name of the template, included in the binary,
+	// which is used by operations without a Template. The only builtin
+	// template is "default", which documents the messages, enums, and services
+	// of the target file. It defaults to "default".
+	BuiltinTemplate string `json:"builtinTemplate" yaml:"builtinTemplate"`
+
+	// HideDeprecated excludes messages, enums, and services with the
+	// deprecated option from allMessages, allEnums, services, and
+	// allServices.
+	HideDeprecated bool `json:"hideDeprecated" yaml:"hideDeprecated"`
+
+	// SlugAnchors replaces the anchors of types and methods with their slug,
+	// e.g. "outer-inner" instead of "Outer.Inner", in the same way as
+	// GitHub-flavored headings. Symbols on the same page with the same slug
+	// are disambiguated with a numeric suffix, e.g. "outer-inner-1".
+	SlugAnchors bool `json:"slugAnchors" yaml:"slugAnchors"`
+
+	// SlugPageNames slugs the names of the pages generated for proto files,
+	// e.g. "api/user-service.html" for "api/user_service.proto". It applies
+	// to the outputs of the default operations, to {{.Name}} in the Output
+	// of an operation with a Target pattern, and to the links created by
+	// typeURL and methodURL, so that the links match the generated pages.
+	SlugPageNames bool `json:"slugPageNames" yaml:"slugPageNames"`
+
+	// Funcs are additional functions for the templates of all operations, for
+	// programs which embed the generator. The names must not be used by the
+	// builtin functions. Each function must return one value, or two values
+	// where the second is an error, as required by html/template. A function
+	// which returns a non-nil error stops the execution of the template with
+	// that error. Arguments are converted from the template the same way as
+	// for builtin functions, and functions may be variadic.
+	Funcs template.FuncMap `json:"-" yaml:"-"`
+
+	// MessageCard configures the messageCard function.
+	MessageCard MessageCardOptions `json:"messageCard" yaml:"messageCard"`
+}
+
+// MarkdownOptions configure the rendering of markdown comments. Definition
+// lists, tables, and fenced code blocks are always rendered.
+type MarkdownOptions struct {
+	// TaskLists renders GitHub style task list items ("- [ ] todo" and
+	// "- [x] done") as checkboxes.
+	TaskLists bool `json:"taskLists" yaml:"taskLists"`
+
+	// Sanitize selects the policy used to remove unsafe html, such as script
+	// elements and javascript: links, from the rendered markdown. Valid values
+	// are "ugc" (the default, which allows the formatting markdown produces),
+	// "strict" (which removes all html and leaves only text), and "none" (which
+	// does not sanitize, for trusted comments).
+	Sanitize string `json:"sanitize" yaml:"sanitize"`
+}
+
+// MessageCardOptions configure the cards rendered by messageCard.
+type MessageCardOptions struct {
+	// Columns are the contents of the card, in order. Valid values are "name"
+	// (the name of the message linked to its documentation), "fields" (the
+	// number of fields), and "summary" (the first paragraph of the comment).
+	// When empty all columns are rendered.
+	Columns []string `json:"columns" yaml:"columns"`
+}
+
+// int64AsString returns the value of Int64AsString, or its default.
+func (c Config) int64AsString() bool {
+	return c.Int64AsString == nil || *c.Int64AsString
 }
@@ -0,0 +1,66 @@
+package tmpl
+
+import (
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// undocumented returns the fully-qualified names of the messages, fields,
+// enums, and methods in file which have no leading or trailing comment, in
+// declaration order. Files without source code info have no comments, so
+// every element is returned. The synthetic entry messages of map fields are
+// not declared in the proto file, so they and their fields are skipped.
+func undocumented(file *descriptor.FileDescriptorProto) []string {
+	documented := make(map[interface{}]bool)
+	for _, loc := range file.GetSourceCodeInfo().GetLocation() {
+		if loc.GetLeadingComments() == "" && loc.GetTrailingComments() == "" {
+			continue
+		}
+		// Locations of repeated elements, such as an extend block, are not
+		// nodes, see isNodeKey.
+		if node := walkPath(loc.Path, file); isNodeKey(node) {
+			documented[node] = true
+		}
+	}
+
+	var (
+		all  []string
+		pkg  string
+		walk func(symbolPath string, msg *descriptor.DescriptorProto)
+	)
+	if file.GetPackage() != "" {
+		pkg = "." + file.GetPackage()
+	}
+	check := func(node interface{}, fullName string) {
+		if !documented[node] {
+			all = append(all, fullName)
+		}
+	}
+	walk = func(symbolPath string, msg *descriptor.DescriptorProto) {
+		if msg.GetOptions().GetMapEntry() {
+			return
+		}
+		check(msg, symbolPath)
+		for _, field := range msg.GetField() {
+			check(field, symbolPath+"."+field.GetName())
+		}
+		for _, nested := range msg.GetNestedType() {
+			walk(symbolPath+"."+nested.GetName(), nested)
+		}
+		for _, enum := range msg.GetEnumType() {
+			check(enum, symbolPath+"."+enum.GetName())
+		}
+	}
+
+	for _, msg := range file.GetMessageType() {
+		walk(pkg+"."+msg.GetName(), msg)
+	}
+	for _, enum := range file.GetEnumType() {
+		check(enum, pkg+"."+enum.GetName())
+	}
+	for _, service := range file.GetService() {
+		for _, method := range service.GetMethod() {
+			check(method, pkg+"."+service.GetName()+"."+method.GetName())
+		}
+	}
+	return all
+}
@@ -0,0 +1,106 @@
+package tmpl
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestUndocumented(t *testing.T) {
+	file := &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+		MessageType: []*descriptor.DescriptorProto{
+			{
+				Name: proto.String("Documented"),
+				Field: []*descriptor.FieldDescriptorProto{
+					{Name: proto.String("documented")},
+					{Name: proto.String("bare")},
+				},
+			},
+			{Name: proto.String("Bare")},
+		},
+		EnumType: []*descriptor.EnumDescriptorProto{
+			{Name: proto.String("Kind")},
+		},
+		Service: []*descriptor.ServiceDescriptorProto{
+			{
+				Name: proto.String("Things"),
+				Method: []*descriptor.MethodDescriptorProto{
+					{Name: proto.String("Get")},
+					{Name: proto.String("List")},
+				},
+			},
+		},
+		SourceCodeInfo: &descriptor.SourceCodeInfo{
+			Location: []*descriptor.SourceCodeInfo_Location{
+				// message Documented
+				{Path: []int32{4, 0}, LeadingComments: proto.String(" A message.\n")},
+				// Documented.documented
+				{Path: []int32{4, 0, 2, 0}, TrailingComments: proto.String(" A field.\n")},
+				// Documented.bare
+				{Path: []int32{4, 0, 2, 1}},
+				// enum Kind
+				{Path: []int32{5, 0}, LeadingComments: proto.String(" An enum.\n")},
+				// Things.List
+				{Path: []int32{6, 0, 2, 1}, LeadingComments: proto.String(" A method.\n")},
+			},
+		},
+	}
+
+	got := undocumented(file)
+	expected := []string{
+		".foo.Documented.bare",
+		".foo.Bare",
+		".foo.Things.Get",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %v expected %v", got, expected)
+	}
+}
+
+func TestUndocumentedExtendAndMapEntry(t *testing.T) {
+	file := &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+		MessageType: []*descriptor.DescriptorProto{
+			{
+				Name: proto.String("Foo"),
+				Field: []*descriptor.FieldDescriptorProto{
+					{Name: proto.String("labels"), Label: descriptor.FieldDescriptorProto_LABEL_REPEATED.Enum()},
+				},
+				NestedType: []*descriptor.DescriptorProto{
+					{
+						Name: proto.String("LabelsEntry"),
+						Field: []*descriptor.FieldDescriptorProto{
+							{Name: proto.String("key")},
+							{Name: proto.String("value")},
+						},
+						Options: &descriptor.MessageOptions{MapEntry: proto.Bool(true)},
+					},
+				},
+			},
+		},
+		Extension: []*descriptor.FieldDescriptorProto{
+			{Name: proto.String("ext"), Extendee: proto.String(".foo.Foo")},
+		},
+		SourceCodeInfo: &descriptor.SourceCodeInfo{
+			Location: []*descriptor.SourceCodeInfo_Location{
+				// message Foo
+				{Path: []int32{4, 0}, LeadingComments: proto.String(" A message.\n")},
+				// Foo.labels
+				{Path: []int32{4, 0, 2, 0}, LeadingComments: proto.String(" Labels.\n")},
+				// extend Foo
+				{Path: []int32{7}, LeadingComments: proto.String(" Extensions of Foo.\n")},
+				// message Foo, extensions declared inside of a message
+				{Path: []int32{4, 0, 6}, LeadingComments: proto.String(" Nested extensions.\n")},
+			},
+		},
+	}
+
+	if got := undocumented(file); len(got) != 0 {
+		t.Fatalf("expected no undocumented elements, got %v", got)
+	}
+}
@@ -0,0 +1,26 @@
+package tmpl
+
+import "github.com/golang/protobuf/protoc-gen-go/descriptor"
+
+// isDeprecated returns true if the deprecated option is set on the file,
+// message, field, enum, enum value, service, or method.
+func isDeprecated(x interface{}) bool {
+	switch v := x.(type) {
+	case *descriptor.FileDescriptorProto:
+		return v.GetOptions().GetDeprecated()
+	case *descriptor.DescriptorProto:
+		return v.GetOptions().GetDeprecated()
+	case *descriptor.FieldDescriptorProto:
+		return v.GetOptions().GetDeprecated()
+	case *descriptor.EnumDescriptorProto:
+		return v.GetOptions().GetDeprecated()
+	case *descriptor.EnumValueDescriptorProto:
+		return v.GetOptions().GetDeprecated()
+	case *descriptor.ServiceDescriptorProto:
+		return v.GetOptions().GetDeprecated()
+	case *descriptor.MethodDescriptorProto:
+		return v.GetOptions().GetDeprecated()
+	default:
+		return false
+	}
+}
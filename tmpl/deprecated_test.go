@@ -0,0 +1,89 @@
+package tmpl
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
+)
+
+func TestIsDeprecated(t *testing.T) {
+	deprecated := proto.Bool(true)
+	var testCases = []struct {
+		name string
+		node interface{}
+	}{
+		{name: "file", node: &descriptor.FileDescriptorProto{Options: &descriptor.FileOptions{Deprecated: deprecated}}},
+		{name: "message", node: &descriptor.DescriptorProto{Options: &descriptor.MessageOptions{Deprecated: deprecated}}},
+		{name: "field", node: &descriptor.FieldDescriptorProto{Options: &descriptor.FieldOptions{Deprecated: deprecated}}},
+		{name: "enum", node: &descriptor.EnumDescriptorProto{Options: &descriptor.EnumOptions{Deprecated: deprecated}}},
+		{name: "enum value", node: &descriptor.EnumValueDescriptorProto{Options: &descriptor.EnumValueOptions{Deprecated: deprecated}}},
+		{name: "service", node: &descriptor.ServiceDescriptorProto{Options: &descriptor.ServiceOptions{Deprecated: deprecated}}},
+		{name: "method", node: &descriptor.MethodDescriptorProto{Options: &descriptor.MethodOptions{Deprecated: deprecated}}},
+	}
+	for _, testCase := range testCases {
+		if !isDeprecated(testCase.node) {
+			t.Errorf("expected deprecated %s", testCase.name)
+		}
+	}
+
+	for _, node := range []interface{}{
+		&descriptor.DescriptorProto{},
+		&descriptor.FieldDescriptorProto{Options: &descriptor.FieldOptions{Deprecated: proto.Bool(false)}},
+		&descriptor.MethodDescriptorProto{Options: &descriptor.MethodOptions{}},
+		"not a descriptor",
+	} {
+		if isDeprecated(node) {
+			t.Errorf("expected %v not to be deprecated", node)
+		}
+	}
+}
+
+func TestHideDeprecated(t *testing.T) {
+	deprecated := proto.Bool(true)
+	file := &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+		MessageType: []*descriptor.DescriptorProto{
+			{Name: proto.String("Old"), Options: &descriptor.MessageOptions{Deprecated: deprecated}},
+			{Name: proto.String("New")},
+		},
+		EnumType: []*descriptor.EnumDescriptorProto{
+			{Name: proto.String("OldKind"), Options: &descriptor.EnumOptions{Deprecated: deprecated}},
+			{Name: proto.String("Kind")},
+		},
+		Service: []*descriptor.ServiceDescriptorProto{
+			{Name: proto.String("OldThings"), Options: &descriptor.ServiceOptions{Deprecated: deprecated}},
+			{Name: proto.String("Things")},
+		},
+	}
+	request := &plugin.CodeGeneratorRequest{
+		FileToGenerate: []string{"foo.proto"},
+		ProtoFile:      []*descriptor.FileDescriptorProto{file},
+	}
+	f := &tmplFuncs{
+		protoFileDescriptor: file,
+		outputFile:          "foo.html",
+		protoFiles:          request.ProtoFile,
+		config:              Config{HideDeprecated: true},
+	}
+
+	if got := f.allMessages(file); len(got) != 1 || got[0].GetName() != "New" {
+		t.Fatalf("expected only New, got %v", got)
+	}
+	if got := f.allEnums(file); len(got) != 1 || got[0].GetName() != "Kind" {
+		t.Fatalf("expected only Kind, got %v", got)
+	}
+	if got := f.services(file); len(got) != 1 || got[0].GetName() != "Things" {
+		t.Fatalf("expected only Things, got %v", got)
+	}
+	if got := f.allServices(request); len(got) != 1 || got[0].Service.GetName() != "Things" {
+		t.Fatalf("expected only Things, got %v", got)
+	}
+
+	f.config.HideDeprecated = false
+	if got := f.allMessages(file); len(got) != 2 {
+		t.Fatalf("expected 2 messages, got %v", got)
+	}
+}
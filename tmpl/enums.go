@@ -0,0 +1,93 @@
+package tmpl
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// enumGap is a range of consecutive numbers which are not used by any value of
+// an enum. First and Last are inclusive, and equal for a single number.
+type enumGap struct {
+	First int32
+	Last  int32
+}
+
+// enumGaps returns the ranges of numbers which are missing between the lowest
+// and highest value numbers of the enum, in ascending order. Aliases (values
+// sharing a number) are only counted once. One range is returned per gap, so
+// large gaps, e.g. before a MAX = 2147483647 sentinel, are cheap.
+func enumGaps(enum *descriptor.EnumDescriptorProto) []enumGap {
+	if len(enum.GetValue()) == 0 {
+		return nil
+	}
+	used := make(map[int32]bool, len(enum.GetValue()))
+	for _, v := range enum.GetValue() {
+		used[v.GetNumber()] = true
+	}
+	numbers := make([]int, 0, len(used))
+	for n := range used {
+		numbers = append(numbers, int(n))
+	}
+	sort.Ints(numbers)
+
+	var gaps []enumGap
+	for i := 1; i < len(numbers); i++ {
+		if numbers[i]-numbers[i-1] > 1 {
+			gaps = append(gaps, enumGap{First: int32(numbers[i-1] + 1), Last: int32(numbers[i] - 1)})
+		}
+	}
+	return gaps
+}
+
+// defaultEnumPalette is the palette used by enumValueColor when
+// Config.EnumPalette is empty.
+var defaultEnumPalette = []string{
+	"#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd",
+	"#8c564b", "#e377c2", "#7f7f7f", "#bcbd22", "#17becf",
+}
+
+// enumValueColor returns a color from the palette for the named value of the
+// enum. The color is chosen by hashing the name and number of the value, so a
+// value always has the same color on every page.
+func (f *tmplFuncs) enumValueColor(enum *descriptor.EnumDescriptorProto, valueName string) string {
+	palette := f.config.EnumPalette
+	if len(palette) == 0 {
+		palette = defaultEnumPalette
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(valueName))
+	for _, v := range enum.GetValue() {
+		if v.GetName() == valueName {
+			h.Write([]byte(strconv.Itoa(int(v.GetNumber()))))
+			break
+		}
+	}
+	return palette[h.Sum32()%uint32(len(palette))]
+}
+
+// enumValueNumber returns the number of the enum value.
+func enumValueNumber(value *descriptor.EnumValueDescriptorProto) int32 {
+	return value.GetNumber()
+}
+
+// enumAllowsAlias returns true if the allow_alias option is set on the enum,
+// which allows more than one value to have the same number.
+func enumAllowsAlias(enum *descriptor.EnumDescriptorProto) bool {
+	return enum.GetOptions().GetAllowAlias()
+}
+
+// enumValueAliases returns the names of the other values of the enum which have
+// the same number as the value, in declaration order.
+func enumValueAliases(enum *descriptor.EnumDescriptorProto, value *descriptor.EnumValueDescriptorProto) []string {
+	var aliases []string
+	for _, v := range enum.GetValue() {
+		if v != value && v.GetNumber() == value.GetNumber() {
+			aliases = append(aliases, v.GetName())
+		}
+	}
+	return aliases
+}
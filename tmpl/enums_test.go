@@ -0,0 +1,103 @@
+package tmpl
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func enumValue(name string, number int32) *descriptor.EnumValueDescriptorProto {
+	return &descriptor.EnumValueDescriptorProto{
+		Name:   proto.String(name),
+		Number: proto.Int32(number),
+	}
+}
+
+func TestEnumGaps(t *testing.T) {
+	enum := &descriptor.EnumDescriptorProto{
+		Name: proto.String("Color"),
+		Value: []*descriptor.EnumValueDescriptorProto{
+			enumValue("UNKNOWN", 0),
+			enumValue("RED", 1),
+			enumValue("CRIMSON", 1),
+			enumValue("BLUE", 4),
+			enumValue("GREEN", 6),
+			enumValue("MAX", math.MaxInt32),
+		},
+	}
+	got := enumGaps(enum)
+	expected := []enumGap{
+		{First: 2, Last: 3},
+		{First: 5, Last: 5},
+		{First: 7, Last: math.MaxInt32 - 1},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %v expected %v", got, expected)
+	}
+}
+
+func TestEnumValueColorIsStable(t *testing.T) {
+	enum := &descriptor.EnumDescriptorProto{
+		Name: proto.String("Color"),
+		Value: []*descriptor.EnumValueDescriptorProto{
+			enumValue("RED", 0),
+			enumValue("BLUE", 1),
+		},
+	}
+	f := &tmplFuncs{}
+	first := f.enumValueColor(enum, "BLUE")
+	for i := 0; i < 10; i++ {
+		if got := f.enumValueColor(enum, "BLUE"); got != first {
+			t.Fatalf("got %q expected %q", got, first)
+		}
+	}
+	// Another funcs, as used by a different page, has the same color.
+	if got := (&tmplFuncs{}).enumValueColor(enum, "BLUE"); got != first {
+		t.Fatalf("got %q expected %q", got, first)
+	}
+}
+
+func TestEnumValueColorPalette(t *testing.T) {
+	enum := &descriptor.EnumDescriptorProto{
+		Name:  proto.String("Color"),
+		Value: []*descriptor.EnumValueDescriptorProto{enumValue("RED", 0)},
+	}
+	f := &tmplFuncs{config: Config{EnumPalette: []string{"red"}}}
+	if got := f.enumValueColor(enum, "RED"); got != "red" {
+		t.Fatalf("got %q expected %q", got, "red")
+	}
+}
+
+func TestEnumValueAliases(t *testing.T) {
+	enum := &descriptor.EnumDescriptorProto{
+		Name:    proto.String("Status"),
+		Options: &descriptor.EnumOptions{AllowAlias: proto.Bool(true)},
+		Value: []*descriptor.EnumValueDescriptorProto{
+			enumValue("UNKNOWN", 0),
+			enumValue("STARTED", 1),
+			enumValue("RUNNING", 1),
+		},
+	}
+
+	if !enumAllowsAlias(enum) {
+		t.Fatal("expected the enum to allow aliases")
+	}
+	if got := enumValueNumber(enum.Value[2]); got != 1 {
+		t.Fatalf("expected number 1, got %d", got)
+	}
+	if got := enumValueAliases(enum, enum.Value[1]); !reflect.DeepEqual(got, []string{"RUNNING"}) {
+		t.Fatalf("expected STARTED to be an alias of RUNNING, got %v", got)
+	}
+	if got := enumValueAliases(enum, enum.Value[2]); !reflect.DeepEqual(got, []string{"STARTED"}) {
+		t.Fatalf("expected RUNNING to be an alias of STARTED, got %v", got)
+	}
+	if got := enumValueAliases(enum, enum.Value[0]); len(got) != 0 {
+		t.Fatalf("expected UNKNOWN to have no aliases, got %v", got)
+	}
+	if enumAllowsAlias(&descriptor.EnumDescriptorProto{}) {
+		t.Fatal("expected an enum without options not to allow aliases")
+	}
+}
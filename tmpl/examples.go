@@ -0,0 +1,184 @@
+package tmpl
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/dnephin/proto-gen-html/util"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// jsonExample returns an example of the JSON representation of the message,
+// with each field set to its default value. Message fields are shown as empty
+// objects, repeated fields as an array with one value, and map fields as an
+// object with one entry. Only the fields returned by fields are included.
+func (f *tmplFuncs) jsonExample(msg *descriptor.DescriptorProto) string {
+	fields := f.fields(msg)
+	if len(fields) == 0 {
+		return "{}"
+	}
+	buf := new(bytes.Buffer)
+	buf.WriteString("{\n")
+	for i, field := range fields {
+		value := f.defaultValue(field)
+		if entry := f.mapEntry(field); entry != nil {
+			value = "{" + f.mapKeyExample(entry.Key) + ": " + f.defaultValue(entry.Value) + "}"
+		} else if field.GetLabel() == descriptor.FieldDescriptorProto_LABEL_REPEATED {
+			value = "[" + value + "]"
+		}
+		buf.WriteString("  " + strconv.Quote(jsonName(field)) + ": " + value)
+		if i < len(fields)-1 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("}")
+	return buf.String()
+}
+
+// mapKeyExample returns the JSON object key of an example entry of a map with
+// the key field. Keys are always strings in JSON, so the zero value of other
+// key types is quoted.
+func (f *tmplFuncs) mapKeyExample(key *descriptor.FieldDescriptorProto) string {
+	if key.GetType() == descriptor.FieldDescriptorProto_TYPE_STRING {
+		return strconv.Quote("key")
+	}
+	return strconv.Quote(strings.Trim(f.defaultValue(key), `"`))
+}
+
+// defaultValue returns the JSON representation of the default value of a
+// single value of the field. This is the default declared in the proto file,
+// or the zero value of the field type. Bytes are base64 encoded, and infinite
+// and NaN floating point defaults are the strings "Infinity", "-Infinity", and
+// "NaN", as in the JSON mapping of proto3.
+func (f *tmplFuncs) defaultValue(field *descriptor.FieldDescriptorProto) string {
+	value := field.GetDefaultValue()
+	switch field.GetType() {
+	case descriptor.FieldDescriptorProto_TYPE_INT64,
+		descriptor.FieldDescriptorProto_TYPE_UINT64,
+		descriptor.FieldDescriptorProto_TYPE_FIXED64,
+		descriptor.FieldDescriptorProto_TYPE_SFIXED64,
+		descriptor.FieldDescriptorProto_TYPE_SINT64:
+		if value == "" {
+			value = "0"
+		}
+		if f.config.int64AsString() {
+			return strconv.Quote(value)
+		}
+		return value
+	case descriptor.FieldDescriptorProto_TYPE_DOUBLE,
+		descriptor.FieldDescriptorProto_TYPE_FLOAT:
+		switch value {
+		case "":
+			return "0"
+		case "inf":
+			return strconv.Quote("Infinity")
+		case "-inf":
+			return strconv.Quote("-Infinity")
+		case "nan":
+			return strconv.Quote("NaN")
+		}
+		return value
+	case descriptor.FieldDescriptorProto_TYPE_INT32,
+		descriptor.FieldDescriptorProto_TYPE_UINT32,
+		descriptor.FieldDescriptorProto_TYPE_FIXED32,
+		descriptor.FieldDescriptorProto_TYPE_SFIXED32,
+		descriptor.FieldDescriptorProto_TYPE_SINT32:
+		if value == "" {
+			return "0"
+		}
+		return value
+	case descriptor.FieldDescriptorProto_TYPE_BOOL:
+		if value == "" {
+			return "false"
+		}
+		return value
+	case descriptor.FieldDescriptorProto_TYPE_STRING:
+		return strconv.Quote(value)
+	case descriptor.FieldDescriptorProto_TYPE_BYTES:
+		return strconv.Quote(base64.StdEncoding.EncodeToString(unescapeBytes(value)))
+	case descriptor.FieldDescriptorProto_TYPE_ENUM:
+		if value == "" {
+			value = f.firstEnumValue(field.GetTypeName())
+		}
+		if value == "" {
+			return "0"
+		}
+		return strconv.Quote(value)
+	default:
+		return "{}"
+	}
+}
+
+// unescapeBytes returns the bytes of the default value of a bytes field, which
+// protoc escapes like a C string, e.g. `\001ab`. The value is returned
+// unchanged if it can not be unescaped.
+func unescapeBytes(value string) []byte {
+	unquoted, err := strconv.Unquote(`"` + strings.Replace(value, `\'`, "'", -1) + `"`)
+	if err != nil {
+		return []byte(value)
+	}
+	return []byte(unquoted)
+}
+
+// fieldDefault returns the default value declared for the field in a proto2
+// file as it is written in the proto file, or an empty string if the field has
+// no declared default. Strings are quoted, bytes are quoted without escaping
+// them again, since protoc already escapes them, and enum defaults are the name
+// of the enum value.
+func (f *tmplFuncs) fieldDefault(field *descriptor.FieldDescriptorProto) string {
+	if field.DefaultValue == nil {
+		return ""
+	}
+	value := field.GetDefaultValue()
+	switch field.GetType() {
+	case descriptor.FieldDescriptorProto_TYPE_STRING:
+		return strconv.Quote(value)
+	case descriptor.FieldDescriptorProto_TYPE_BYTES:
+		return `"` + value + `"`
+	case descriptor.FieldDescriptorProto_TYPE_BOOL:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return value
+		}
+		return strconv.FormatBool(parsed)
+	case descriptor.FieldDescriptorProto_TYPE_ENUM:
+		return f.enumValueName(field.GetTypeName(), value)
+	default:
+		return value
+	}
+}
+
+// enumValueName returns the name of the value of the enum with the
+// fully-qualified symbolPath, where value is either the name or the number of
+// the enum value. The value is returned unchanged if it is not found.
+func (f *tmplFuncs) enumValueName(symbolPath, value string) string {
+	node, _ := util.NewResolver(f.protoFiles).Resolve(symbolPath, nil)
+	enum, ok := node.(*descriptor.EnumDescriptorProto)
+	if !ok {
+		return value
+	}
+	number, err := strconv.ParseInt(value, 10, 32)
+	for _, v := range enum.GetValue() {
+		if v.GetName() == value || (err == nil && int64(v.GetNumber()) == number) {
+			return v.GetName()
+		}
+	}
+	return value
+}
+
+// firstEnumValue returns the name of the first value of the enum with the
+// fully-qualified symbolPath, which is the default value of the enum.
+func (f *tmplFuncs) firstEnumValue(symbolPath string) string {
+	if symbolPath == "" {
+		return ""
+	}
+	node, _ := util.NewResolver(f.protoFiles).Resolve(symbolPath, nil)
+	enum, ok := node.(*descriptor.EnumDescriptorProto)
+	if !ok || len(enum.GetValue()) == 0 {
+		return ""
+	}
+	return enum.GetValue()[0].GetName()
+}
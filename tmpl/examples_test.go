@@ -0,0 +1,203 @@
+package tmpl
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func newExampleMessage() *descriptor.DescriptorProto {
+	return &descriptor.DescriptorProto{
+		Name: proto.String("Foo"),
+		Field: []*descriptor.FieldDescriptorProto{
+			{
+				Name:  proto.String("big_number"),
+				Label: descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:  descriptor.FieldDescriptorProto_TYPE_INT64.Enum(),
+			},
+			{
+				Name:  proto.String("small_number"),
+				Label: descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:  descriptor.FieldDescriptorProto_TYPE_INT32.Enum(),
+			},
+		},
+	}
+}
+
+func TestJSONExampleInt64AsString(t *testing.T) {
+	f := &tmplFuncs{}
+	expected := `{
+  "bigNumber": "0",
+  "smallNumber": 0
+}`
+	if got := f.jsonExample(newExampleMessage()); got != expected {
+		t.Fatalf("got %s expected %s", got, expected)
+	}
+}
+
+func TestJSONExampleInt64AsNumber(t *testing.T) {
+	f := &tmplFuncs{config: Config{Int64AsString: proto.Bool(false)}}
+	expected := `{
+  "bigNumber": 0,
+  "smallNumber": 0
+}`
+	if got := f.jsonExample(newExampleMessage()); got != expected {
+		t.Fatalf("got %s expected %s", got, expected)
+	}
+}
+
+func TestDefaultValueInt64(t *testing.T) {
+	field := &descriptor.FieldDescriptorProto{
+		Name:         proto.String("count"),
+		Type:         descriptor.FieldDescriptorProto_TYPE_UINT64.Enum(),
+		DefaultValue: proto.String("42"),
+	}
+	if got := (&tmplFuncs{}).defaultValue(field); got != `"42"` {
+		t.Fatalf("got %s expected %s", got, `"42"`)
+	}
+	f := &tmplFuncs{config: Config{Int64AsString: proto.Bool(false)}}
+	if got := f.defaultValue(field); got != "42" {
+		t.Fatalf("got %s expected %s", got, "42")
+	}
+}
+
+func TestDefaultValueFloat(t *testing.T) {
+	var testCases = []struct {
+		value    *string
+		expected string
+	}{
+		{expected: "0"},
+		{value: proto.String("0.5"), expected: "0.5"},
+		{value: proto.String("inf"), expected: `"Infinity"`},
+		{value: proto.String("-inf"), expected: `"-Infinity"`},
+		{value: proto.String("nan"), expected: `"NaN"`},
+	}
+	f := &tmplFuncs{}
+	for _, testCase := range testCases {
+		field := &descriptor.FieldDescriptorProto{
+			Name:         proto.String("ratio"),
+			Type:         descriptor.FieldDescriptorProto_TYPE_DOUBLE.Enum(),
+			DefaultValue: testCase.value,
+		}
+		if got := f.defaultValue(field); got != testCase.expected {
+			t.Errorf("%q: got %s expected %s", field.GetDefaultValue(), got, testCase.expected)
+		}
+	}
+}
+
+func TestFieldDefault(t *testing.T) {
+	field := func(name string, fieldType descriptor.FieldDescriptorProto_Type, value *string) *descriptor.FieldDescriptorProto {
+		f := &descriptor.FieldDescriptorProto{
+			Name:         proto.String(name),
+			Label:        descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:         fieldType.Enum(),
+			DefaultValue: value,
+		}
+		if fieldType == descriptor.FieldDescriptorProto_TYPE_ENUM {
+			f.TypeName = proto.String(".foo.Color")
+		}
+		return f
+	}
+	file := &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+		Syntax:  proto.String("proto2"),
+		EnumType: []*descriptor.EnumDescriptorProto{
+			{
+				Name:  proto.String("Color"),
+				Value: []*descriptor.EnumValueDescriptorProto{enumValue("RED", 0), enumValue("BLUE", 1)},
+			},
+		},
+	}
+	f := &tmplFuncs{protoFiles: []*descriptor.FileDescriptorProto{file}}
+
+	var testCases = []struct {
+		field    *descriptor.FieldDescriptorProto
+		expected string
+	}{
+		{field: field("name", descriptor.FieldDescriptorProto_TYPE_STRING, proto.String(`say "hi"`)), expected: `"say \"hi\""`},
+		{field: field("magic", descriptor.FieldDescriptorProto_TYPE_BYTES, proto.String(`\001ab`)), expected: `"\001ab"`},
+		{field: field("count", descriptor.FieldDescriptorProto_TYPE_INT32, proto.String("-3")), expected: "-3"},
+		{field: field("ratio", descriptor.FieldDescriptorProto_TYPE_DOUBLE, proto.String("0.5")), expected: "0.5"},
+		{field: field("enabled", descriptor.FieldDescriptorProto_TYPE_BOOL, proto.String("true")), expected: "true"},
+		{field: field("color", descriptor.FieldDescriptorProto_TYPE_ENUM, proto.String("BLUE")), expected: "BLUE"},
+		{field: field("shade", descriptor.FieldDescriptorProto_TYPE_ENUM, proto.String("1")), expected: "BLUE"},
+		{field: field("unset", descriptor.FieldDescriptorProto_TYPE_STRING, nil), expected: ""},
+	}
+	for _, testCase := range testCases {
+		if got := f.fieldDefault(testCase.field); got != testCase.expected {
+			t.Errorf("%s: got %q expected %q", testCase.field.GetName(), got, testCase.expected)
+		}
+	}
+}
+
+func TestJSONExampleUsesFields(t *testing.T) {
+	f := newTestFuncs(visibilityConfig("PUBLIC"), newVisibilityFile())
+	f.config.Canonical = true
+	msg := &descriptor.DescriptorProto{
+		Name: proto.String("Foo"),
+		Field: []*descriptor.FieldDescriptorProto{
+			visibilityField("second", 0),
+			visibilityField("secret", 2),
+			visibilityField("first", 0),
+		},
+	}
+	for i, number := range []int32{2, 3, 1} {
+		msg.Field[i].Number = proto.Int32(number)
+		msg.Field[i].Type = descriptor.FieldDescriptorProto_TYPE_BOOL.Enum()
+	}
+	expected := `{
+  "first": false,
+  "second": false
+}`
+	if got := f.jsonExample(msg); got != expected {
+		t.Fatalf("got %s expected %s", got, expected)
+	}
+}
+
+func TestJSONExampleMap(t *testing.T) {
+	f := newTestFuncs(Config{}, newMapFile())
+	msg := f.protoFiles[0].MessageType[1]
+	expected := `{
+  "foos": {"key": {}},
+  "foo": {}
+}`
+	if got := f.jsonExample(msg); got != expected {
+		t.Fatalf("got %s expected %s", got, expected)
+	}
+
+	entry := msg.NestedType[0]
+	entry.Field[0].Type = descriptor.FieldDescriptorProto_TYPE_INT32.Enum()
+	entry.Field[1].Type = descriptor.FieldDescriptorProto_TYPE_BOOL.Enum()
+	entry.Field[1].TypeName = nil
+	expected = `{
+  "foos": {"0": false},
+  "foo": {}
+}`
+	if got := f.jsonExample(msg); got != expected {
+		t.Fatalf("got %s expected %s", got, expected)
+	}
+}
+
+func TestDefaultValueBytes(t *testing.T) {
+	var testCases = []struct {
+		value    *string
+		expected string
+	}{
+		{expected: `""`},
+		{value: proto.String("hello"), expected: `"aGVsbG8="`},
+		{value: proto.String(`\001\'x`), expected: `"ASd4"`},
+	}
+	f := &tmplFuncs{}
+	for _, testCase := range testCases {
+		field := &descriptor.FieldDescriptorProto{
+			Name:         proto.String("data"),
+			Type:         descriptor.FieldDescriptorProto_TYPE_BYTES.Enum(),
+			DefaultValue: testCase.value,
+		}
+		if got := f.defaultValue(field); got != testCase.expected {
+			t.Errorf("%q: got %s expected %s", field.GetDefaultValue(), got, testCase.expected)
+		}
+	}
+}
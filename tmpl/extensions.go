@@ -0,0 +1,42 @@
+package tmpl
+
+import "github.com/golang/protobuf/protoc-gen-go/descriptor"
+
+// extensionEntry is an extension field declared in a file or message.
+type extensionEntry struct {
+	Field *descriptor.FieldDescriptorProto
+	// Extendee is the fully-qualified name of the extended message, which can
+	// be passed to typeURL.
+	Extendee string
+	Number   int32
+	// Type is the type of the field, displayed like fieldType.
+	Type string
+	// TypeName is the fully-qualified name of the type of message and enum
+	// fields, which can be passed to typeURL, or empty for scalar fields.
+	TypeName string
+}
+
+// extensions returns the extensions declared at the top-level of a file, or in
+// the scope of a message, in declaration order. Extensions declared in nested
+// messages are returned by the nested message.
+func (f *tmplFuncs) extensions(x interface{}) []extensionEntry {
+	var fields []*descriptor.FieldDescriptorProto
+	switch v := x.(type) {
+	case *descriptor.FileDescriptorProto:
+		fields = v.GetExtension()
+	case *descriptor.DescriptorProto:
+		fields = v.GetExtension()
+	}
+
+	var all []extensionEntry
+	for _, field := range fields {
+		all = append(all, extensionEntry{
+			Field:    field,
+			Extendee: field.GetExtendee(),
+			Number:   field.GetNumber(),
+			Type:     f.displayFieldType(field),
+			TypeName: field.GetTypeName(),
+		})
+	}
+	return all
+}
@@ -0,0 +1,81 @@
+package tmpl
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func newExtensionsFile() *descriptor.FileDescriptorProto {
+	return &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+		Syntax:  proto.String("proto2"),
+		MessageType: []*descriptor.DescriptorProto{
+			{
+				Name: proto.String("Base"),
+				ExtensionRange: []*descriptor.DescriptorProto_ExtensionRange{
+					{Start: proto.Int32(100), End: proto.Int32(200)},
+				},
+			},
+			{
+				Name: proto.String("Scope"),
+				Extension: []*descriptor.FieldDescriptorProto{
+					{
+						Name:     proto.String("scoped"),
+						Number:   proto.Int32(101),
+						Label:    descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+						Type:     descriptor.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+						TypeName: proto.String(".foo.Scope"),
+						Extendee: proto.String(".foo.Base"),
+					},
+				},
+			},
+		},
+		Extension: []*descriptor.FieldDescriptorProto{
+			{
+				Name:     proto.String("note"),
+				Number:   proto.Int32(100),
+				Label:    descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:     descriptor.FieldDescriptorProto_TYPE_STRING.Enum(),
+				Extendee: proto.String(".foo.Base"),
+			},
+		},
+	}
+}
+
+func TestExtensionsOfFile(t *testing.T) {
+	f := newTestFuncs(Config{}, newExtensionsFile())
+
+	got := f.extensions(f.protoFileDescriptor)
+	if len(got) != 1 {
+		t.Fatalf("expected 1 extension, got %d", len(got))
+	}
+	ext := got[0]
+	if ext.Field.GetName() != "note" || ext.Number != 100 || ext.Type != "string" || ext.TypeName != "" {
+		t.Fatalf("unexpected extension %+v", ext)
+	}
+	if url := f.typeURL(ext.Extendee); url != "foo.html#Base" {
+		t.Fatalf("expected the extendee to link to foo.html#Base, got %q", url)
+	}
+}
+
+func TestExtensionsOfMessage(t *testing.T) {
+	f := newTestFuncs(Config{}, newExtensionsFile())
+
+	got := f.extensions(f.protoFileDescriptor.MessageType[1])
+	if len(got) != 1 {
+		t.Fatalf("expected 1 extension, got %d", len(got))
+	}
+	ext := got[0]
+	if ext.Field.GetName() != "scoped" || ext.Number != 101 || ext.Type != "Scope" {
+		t.Fatalf("unexpected extension %+v", ext)
+	}
+	if url := f.typeURL(ext.TypeName); url != "foo.html#Scope" {
+		t.Fatalf("expected the type to link to foo.html#Scope, got %q", url)
+	}
+	if got := f.extensions(f.protoFileDescriptor.MessageType[0]); len(got) != 0 {
+		t.Fatalf("expected no extensions in Base, got %+v", got)
+	}
+}
@@ -0,0 +1,266 @@
+package tmpl
+
+import (
+	"unicode"
+
+	"github.com/dnephin/proto-gen-html/util"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/pkg/errors"
+)
+
+// fieldName returns the name of the field formatted in the configured
+// Config.FieldNameStyle.
+func (f *tmplFuncs) fieldName(field *descriptor.FieldDescriptorProto) string {
+	switch f.config.FieldNameStyle {
+	case "json":
+		return jsonName(field)
+	case "original":
+		return field.GetName()
+	default:
+		return snakeCase(field.GetName())
+	}
+}
+
+// fieldNumber returns the number of the field, extension, or enum value. The
+// number of a group can be looked up from either the group field or the
+// message type of the group.
+func (f *tmplFuncs) fieldNumber(x interface{}) (int32, error) {
+	switch v := x.(type) {
+	case *descriptor.FieldDescriptorProto:
+		return v.GetNumber(), nil
+	case extensionEntry:
+		return v.Number, nil
+	case *descriptor.EnumValueDescriptorProto:
+		return v.GetNumber(), nil
+	case *descriptor.DescriptorProto:
+		if field := f.groupField(v); field != nil {
+			return field.GetNumber(), nil
+		}
+		return 0, errors.Errorf("message %s is not a group", v.GetName())
+	default:
+		return 0, errors.Errorf("no field number for %T", x)
+	}
+}
+
+// groupField returns the group field or extension which has the message as
+// its type, or nil if the message is not the type of a group. The message of
+// a group is declared in the same scope as the group.
+func (f *tmplFuncs) groupField(msg *descriptor.DescriptorProto) *descriptor.FieldDescriptorProto {
+	find := func(fields []*descriptor.FieldDescriptorProto, types []*descriptor.DescriptorProto) *descriptor.FieldDescriptorProto {
+		for _, field := range fields {
+			if field.GetType() != descriptor.FieldDescriptorProto_TYPE_GROUP {
+				continue
+			}
+			for _, t := range types {
+				if t == msg && t.GetName() == typeBaseName(field.GetTypeName()) {
+					return field
+				}
+			}
+		}
+		return nil
+	}
+
+	for _, file := range f.protoFiles {
+		if field := find(file.GetExtension(), file.GetMessageType()); field != nil {
+			return field
+		}
+		for _, parent := range util.AllMessages(file) {
+			if field := find(parent.GetField(), parent.GetNestedType()); field != nil {
+				return field
+			}
+			if field := find(parent.GetExtension(), parent.GetNestedType()); field != nil {
+				return field
+			}
+		}
+	}
+	return nil
+}
+
+// jsonName returns the JSON name of the field. protoc sets the JsonName of every
+// field, but if it is missing it is derived from the field name the same way
+// protoc does it.
+func jsonName(field *descriptor.FieldDescriptorProto) string {
+	if field.JsonName != nil {
+		return field.GetJsonName()
+	}
+
+	var (
+		out   []rune
+		upper bool
+	)
+	for _, r := range field.GetName() {
+		switch {
+		case r == '_':
+			upper = true
+		case upper:
+			out = append(out, unicode.ToUpper(r))
+			upper = false
+		default:
+			out = append(out, r)
+		}
+	}
+	return string(out)
+}
+
+// snakeCase converts a camelCase name to lower_snake_case. Names which are
+// already snake case are returned unchanged.
+func snakeCase(name string) string {
+	var out []rune
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])) {
+				out = append(out, '_')
+			}
+			r = unicode.ToLower(r)
+		}
+		out = append(out, r)
+	}
+	return string(out)
+}
+
+// presenceBadge returns "optional" for proto3 fields which were declared with
+// the optional keyword, and so have explicit presence. It returns an empty
+// string for all other fields, including proto2 fields, whose labels are
+// rendered by labelString.
+func presenceBadge(field *descriptor.FieldDescriptorProto) string {
+	if proto3Optional(field) {
+		return "optional"
+	}
+	return ""
+}
+
+// isPacked returns true if the repeated scalar field is encoded in the packed
+// format. An explicit packed option is used when it is set, otherwise fields of
+// proto3 files are packed by default and fields of proto2 files are not.
+func (f *tmplFuncs) isPacked(field *descriptor.FieldDescriptorProto) bool {
+	if field.GetLabel() != descriptor.FieldDescriptorProto_LABEL_REPEATED || !packable(field.GetType()) {
+		return false
+	}
+	if options := field.GetOptions(); options != nil && options.Packed != nil {
+		return options.GetPacked()
+	}
+	return f.fieldFile(field).GetSyntax() == "proto3"
+}
+
+// packable returns true for the scalar types which can use the packed format.
+func packable(t descriptor.FieldDescriptorProto_Type) bool {
+	switch t {
+	case descriptor.FieldDescriptorProto_TYPE_STRING,
+		descriptor.FieldDescriptorProto_TYPE_BYTES,
+		descriptor.FieldDescriptorProto_TYPE_MESSAGE,
+		descriptor.FieldDescriptorProto_TYPE_GROUP:
+		return false
+	}
+	return true
+}
+
+// fieldFile returns the file which declares the field, or the target file if
+// the field is not found.
+func (f *tmplFuncs) fieldFile(field *descriptor.FieldDescriptorProto) *descriptor.FileDescriptorProto {
+	for _, file := range f.protoFiles {
+		for _, msg := range util.AllMessages(file) {
+			for _, v := range msg.GetField() {
+				if v == field {
+					return file
+				}
+			}
+		}
+	}
+	return f.protoFileDescriptor
+}
+
+// usesReservedNumber returns the fields of the message with a number in one of
+// the reserved ranges of the message. protoc rejects such messages, so any
+// field returned indicates a descriptor which was not produced by protoc.
+func usesReservedNumber(msg *descriptor.DescriptorProto) []*descriptor.FieldDescriptorProto {
+	var fields []*descriptor.FieldDescriptorProto
+	for _, field := range msg.GetField() {
+		for _, r := range messageReservedRanges(msg) {
+			if field.GetNumber() >= r.Start && field.GetNumber() < r.End {
+				fields = append(fields, field)
+				break
+			}
+		}
+	}
+	return fields
+}
+
+// proto3OptionalFieldNumber is the number of the proto3_optional field of
+// FieldDescriptorProto.
+const proto3OptionalFieldNumber = 17
+
+// proto3Optional returns true if the field is a proto3 optional field. The
+// descriptor package predates proto3_optional, so the value is read from the
+// unrecognized fields of the descriptor.
+func proto3Optional(field *descriptor.FieldDescriptorProto) bool {
+	value, ok := unknownVarint(field.XXX_unrecognized, proto3OptionalFieldNumber)
+	return ok && value != 0
+}
+
+// unknownVarint returns the value of the varint field number from the encoded
+// unrecognized fields of a message.
+func unknownVarint(unrecognized []byte, number uint64) (uint64, bool) {
+	var (
+		value uint64
+		found bool
+	)
+	unknownFields(unrecognized, func(key, v uint64, _ []byte) bool {
+		if key>>3 == number && key&0x7 == proto.WireVarint {
+			value, found = v, true
+			return false
+		}
+		return true
+	})
+	return value, found
+}
+
+// unknownBytes returns the value of the length-delimited field number, such as
+// a string, from the encoded unrecognized fields of a message.
+func unknownBytes(unrecognized []byte, number uint64) ([]byte, bool) {
+	var (
+		value []byte
+		found bool
+	)
+	unknownFields(unrecognized, func(key, _ uint64, raw []byte) bool {
+		if key>>3 == number && key&0x7 == proto.WireBytes {
+			value, found = raw, true
+			return false
+		}
+		return true
+	})
+	return value, found
+}
+
+// unknownFields decodes the encoded fields and calls fn with the key and value
+// of each field, until fn returns false. Length-delimited values are passed as
+// raw, and all other values as value.
+func unknownFields(unrecognized []byte, fn func(key, value uint64, raw []byte) bool) {
+	buf := proto.NewBuffer(unrecognized)
+	for {
+		key, err := buf.DecodeVarint()
+		if err != nil {
+			return
+		}
+		var (
+			value uint64
+			raw   []byte
+		)
+		switch key & 0x7 {
+		case proto.WireVarint:
+			value, err = buf.DecodeVarint()
+		case proto.WireFixed64:
+			value, err = buf.DecodeFixed64()
+		case proto.WireFixed32:
+			value, err = buf.DecodeFixed32()
+		case proto.WireBytes:
+			raw, err = buf.DecodeRawBytes(true)
+		default:
+			return
+		}
+		if err != nil || !fn(key, value, raw) {
+			return
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package tmpl
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestFieldNameStyle(t *testing.T) {
+	field := &descriptor.FieldDescriptorProto{Name: proto.String("my_field")}
+	var tests = map[string]string{
+		"":         "my_field",
+		"proto":    "my_field",
+		"json":     "myField",
+		"original": "my_field",
+	}
+	for style, expected := range tests {
+		f := &tmplFuncs{config: Config{FieldNameStyle: style}}
+		if got := f.fieldName(field); got != expected {
+			t.Fatalf("style %q: got %q expected %q", style, got, expected)
+		}
+	}
+}
+
+func TestFieldNameProtoStyleFromCamelCase(t *testing.T) {
+	field := &descriptor.FieldDescriptorProto{Name: proto.String("myField")}
+	f := &tmplFuncs{config: Config{FieldNameStyle: "proto"}}
+	if got := f.fieldName(field); got != "my_field" {
+		t.Fatalf("got %q expected %q", got, "my_field")
+	}
+}
+
+func TestJSONName(t *testing.T) {
+	var tests = map[string]string{
+		"name":             "name",
+		"display_name":     "displayName",
+		"created_at_time":  "createdAtTime",
+		"double__under":    "doubleUnder",
+		"address_line_2":   "addressLine2",
+		"alreadyCamelCase": "alreadyCamelCase",
+	}
+	for name, expected := range tests {
+		field := &descriptor.FieldDescriptorProto{Name: proto.String(name)}
+		if got := jsonName(field); got != expected {
+			t.Errorf("%q: got %q expected %q", name, got, expected)
+		}
+	}
+}
+
+func TestJSONNameFromDescriptor(t *testing.T) {
+	field := &descriptor.FieldDescriptorProto{
+		Name:     proto.String("display_name"),
+		JsonName: proto.String("label"),
+	}
+	if got := jsonName(field); got != "label" {
+		t.Fatalf("got %q expected %q", got, "label")
+	}
+}
+
+func TestPresenceBadge(t *testing.T) {
+	optional := &descriptor.FieldDescriptorProto{
+		Name:       proto.String("count"),
+		Label:      descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:       descriptor.FieldDescriptorProto_TYPE_INT32.Enum(),
+		OneofIndex: proto.Int32(0),
+	}
+	// protoc sets proto3_optional (field 17), which is unknown to the
+	// descriptor package.
+	optional.XXX_unrecognized = []byte{0x88, 0x01, 0x01}
+	file := &descriptor.FileDescriptorProto{
+		Name:   proto.String("foo.proto"),
+		Syntax: proto.String("proto3"),
+		MessageType: []*descriptor.DescriptorProto{
+			{
+				Name: proto.String("Foo"),
+				Field: []*descriptor.FieldDescriptorProto{
+					{
+						Name:  proto.String("plain"),
+						Label: descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+						Type:  descriptor.FieldDescriptorProto_TYPE_INT32.Enum(),
+					},
+					optional,
+				},
+				OneofDecl: []*descriptor.OneofDescriptorProto{
+					{Name: proto.String("_count")},
+				},
+			},
+		},
+	}
+	// Round trip the file to match what is received from protoc.
+	data, err := proto.Marshal(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file = &descriptor.FileDescriptorProto{}
+	if err := proto.Unmarshal(data, file); err != nil {
+		t.Fatal(err)
+	}
+
+	fields := file.MessageType[0].Field
+	if got := presenceBadge(fields[0]); got != "" {
+		t.Fatalf("got %q expected no badge for a plain field", got)
+	}
+	if got := presenceBadge(fields[1]); got != "optional" {
+		t.Fatalf("got %q expected %q", got, "optional")
+	}
+}
+
+func TestIsPacked(t *testing.T) {
+	repeatedInt32 := func(options *descriptor.FieldOptions) *descriptor.FieldDescriptorProto {
+		return &descriptor.FieldDescriptorProto{
+			Name:    proto.String("values"),
+			Number:  proto.Int32(1),
+			Label:   descriptor.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+			Type:    descriptor.FieldDescriptorProto_TYPE_INT32.Enum(),
+			Options: options,
+		}
+	}
+	newFile := func(syntax string, field *descriptor.FieldDescriptorProto) *descriptor.FileDescriptorProto {
+		return &descriptor.FileDescriptorProto{
+			Name:   proto.String(syntax + ".proto"),
+			Syntax: proto.String(syntax),
+			MessageType: []*descriptor.DescriptorProto{
+				{Name: proto.String("Foo"), Field: []*descriptor.FieldDescriptorProto{field}},
+			},
+		}
+	}
+
+	proto3Field := repeatedInt32(nil)
+	proto2Field := repeatedInt32(nil)
+	unpackedField := repeatedInt32(&descriptor.FieldOptions{Packed: proto.Bool(false)})
+	packedField := repeatedInt32(&descriptor.FieldOptions{Packed: proto.Bool(true)})
+	stringField := &descriptor.FieldDescriptorProto{
+		Name:  proto.String("names"),
+		Label: descriptor.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+		Type:  descriptor.FieldDescriptorProto_TYPE_STRING.Enum(),
+	}
+	f := &tmplFuncs{protoFiles: []*descriptor.FileDescriptorProto{
+		newFile("proto3", proto3Field),
+		newFile("proto2", proto2Field),
+		newFile("proto3", unpackedField),
+		newFile("proto2", packedField),
+		newFile("proto3", stringField),
+	}}
+
+	var testCases = []struct {
+		doc      string
+		field    *descriptor.FieldDescriptorProto
+		expected bool
+	}{
+		{doc: "proto3 default", field: proto3Field, expected: true},
+		{doc: "proto2 default", field: proto2Field, expected: false},
+		{doc: "proto3 packed=false", field: unpackedField, expected: false},
+		{doc: "proto2 packed=true", field: packedField, expected: true},
+		{doc: "proto3 string", field: stringField, expected: false},
+	}
+	for _, testCase := range testCases {
+		if got := f.isPacked(testCase.field); got != testCase.expected {
+			t.Errorf("%s: got %v expected %v", testCase.doc, got, testCase.expected)
+		}
+	}
+}
+
+func TestUsesReservedNumber(t *testing.T) {
+	msg := &descriptor.DescriptorProto{
+		Name: proto.String("Thing"),
+		Field: []*descriptor.FieldDescriptorProto{
+			{Name: proto.String("id"), Number: proto.Int32(1)},
+			{Name: proto.String("old"), Number: proto.Int32(5)},
+			{Name: proto.String("last"), Number: proto.Int32(10)},
+		},
+		ReservedRange: []*descriptor.DescriptorProto_ReservedRange{
+			{Start: proto.Int32(4), End: proto.Int32(10)},
+		},
+	}
+	got := usesReservedNumber(msg)
+	if len(got) != 1 || got[0].GetName() != "old" {
+		t.Fatalf("expected only field old, got %v", got)
+	}
+
+	msg.Field = msg.Field[:1]
+	if got := usesReservedNumber(msg); len(got) != 0 {
+		t.Fatalf("expected no fields, got %v", got)
+	}
+}
+
+func TestFieldNumber(t *testing.T) {
+	group := &descriptor.DescriptorProto{Name: proto.String("Result")}
+	groupField := &descriptor.FieldDescriptorProto{
+		Name:     proto.String("result"),
+		Number:   proto.Int32(7),
+		Type:     descriptor.FieldDescriptorProto_TYPE_GROUP.Enum(),
+		TypeName: proto.String(".foo.Search.Result"),
+	}
+	field := &descriptor.FieldDescriptorProto{Name: proto.String("query"), Number: proto.Int32(1)}
+	ext := &descriptor.FieldDescriptorProto{
+		Name:     proto.String("tag"),
+		Number:   proto.Int32(100),
+		Type:     descriptor.FieldDescriptorProto_TYPE_STRING.Enum(),
+		Extendee: proto.String(".foo.Search"),
+	}
+	value := &descriptor.EnumValueDescriptorProto{Name: proto.String("ON"), Number: proto.Int32(2)}
+	file := &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+		MessageType: []*descriptor.DescriptorProto{{
+			Name:       proto.String("Search"),
+			Field:      []*descriptor.FieldDescriptorProto{field, groupField},
+			NestedType: []*descriptor.DescriptorProto{group},
+		}},
+		Extension: []*descriptor.FieldDescriptorProto{ext},
+	}
+	f := &tmplFuncs{protoFiles: []*descriptor.FileDescriptorProto{file}}
+
+	var tests = []struct {
+		x        interface{}
+		expected int32
+	}{
+		{field, 1},
+		{groupField, 7},
+		{group, 7},
+		{ext, 100},
+		{f.extensions(file)[0], 100},
+		{value, 2},
+	}
+	for _, test := range tests {
+		got, err := f.fieldNumber(test.x)
+		if err != nil {
+			t.Fatalf("%T: unexpected error: %s", test.x, err)
+		}
+		if got != test.expected {
+			t.Errorf("%T: got %d expected %d", test.x, got, test.expected)
+		}
+	}
+
+	if _, err := f.fieldNumber(file.MessageType[0]); err == nil {
+		t.Error("expected an error for a message which is not a group")
+	}
+	if _, err := f.fieldNumber(file); err == nil {
+		t.Error("expected an error for a file")
+	}
+}
@@ -1,6 +1,7 @@
 package tmpl
 
 import (
+	"encoding/json"
 	"fmt"
 	"html/template"
 	"path"
@@ -11,7 +12,7 @@ import (
 
 	"github.com/dnephin/proto-gen-html/util"
 	"github.com/golang/protobuf/protoc-gen-go/descriptor"
-	"gopkg.in/russross/blackfriday.v2"
+	log "github.com/sirupsen/logrus"
 )
 
 // trimExt strips the extension off the path and returns it.
@@ -23,11 +24,54 @@ func trimExt(s string) string {
 	return s
 }
 
-// cacheItem is a single cache item with a value and a location -- effectively
-// it is just used for searching.
-type cacheItem struct {
-	V interface{}
-	L *descriptor.SourceCodeInfo_Location
+// slug returns a URL-safe version of s. It is lowercased, and each run of
+// characters which are not ASCII letters or digits is replaced with a single
+// "-".
+func slug(s string) string {
+	var out []rune
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if ('a' <= r && r <= 'z') || ('0' <= r && r <= '9') {
+			if dash && len(out) > 0 {
+				out = append(out, '-')
+			}
+			out = append(out, r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	return string(out)
+}
+
+// pagePath returns the path of the generated page for the proto file name,
+// without an extension. Each element of the path is slugged, for example:
+//
+//	pagePath("api/My Service_v1.proto") == "api/my-service-v1"
+func pagePath(name string) string {
+	elems := strings.Split(trimExt(name), "/")
+	for i, elem := range elems {
+		elems[i] = slug(elem)
+	}
+	return strings.Join(elems, "/")
+}
+
+// pageName returns the path of the generated page for the proto file name,
+// without an extension. The path is slugged with pagePath when
+// Config.SlugPageNames is set, otherwise only the extension is removed.
+func (c Config) pageName(name string) string {
+	if c.SlugPageNames {
+		return pagePath(name)
+	}
+	return trimExt(name)
+}
+
+// jsonString returns s encoded as a JSON string, for embedding content in JSON
+// output. The characters <, >, and & are escaped by the encoding, so the result
+// is safe to include in html unescaped.
+func jsonString(s string) template.HTML {
+	out, _ := json.Marshal(s)
+	return template.HTML(out)
 }
 
 // Functions exposed to templates. The user of the package must first preload
@@ -36,9 +80,15 @@ type cacheItem struct {
 type tmplFuncs struct {
 	protoFileDescriptor *descriptor.FileDescriptorProto
 	outputFile          string
-	urlRoot             string
+	urlRoot             string // URL root of the operation, see linkRoot
 	protoFiles          []*descriptor.FileDescriptorProto
-	locCache            []cacheItem
+	files               []*descriptor.FileDescriptorProto // files being generated
+	singleDocument      bool
+	locations           *locationCache
+	config              Config
+	// slugAnchors caches the anchors of the symbols of each page, see
+	// slugAnchor.
+	slugAnchors map[*descriptor.FileDescriptorProto]map[string]string
 }
 
 func newDefaultTemplateFuncs() template.FuncMap {
@@ -48,17 +98,110 @@ func newDefaultTemplateFuncs() template.FuncMap {
 // funcMap returns the function map for feeding into templates.
 func (f *tmplFuncs) funcMap() template.FuncMap {
 	return map[string]interface{}{
-		"labelString":  labelString,
-		"typeBaseName": typeBaseName,
-		"fieldType":    fieldType,
-		"trimExt":      trimExt,
-		"typeURL":      f.typeURL,
-		"location":     f.location,
-		"allMessages":  util.AllMessages,
-		"allEnums":     util.AllEnums,
-		"markdown": func(source string) template.HTML {
-			return template.HTML(blackfriday.Run([]byte(source)))
-		},
+		"labelString":            labelString,
+		"typeBaseName":           typeBaseName,
+		"fieldType":              f.displayFieldType,
+		"fieldName":              f.fieldName,
+		"presenceBadge":          presenceBadge,
+		"trimExt":                trimExt,
+		"slug":                   slug,
+		"pagePath":               pagePath,
+		"typeURL":                f.typeURL,
+		"typeAnchor":             f.typeAnchor,
+		"fullName":               util.FullName,
+		"parentType":             f.parentType,
+		"duplicateSimpleNames":   duplicateSimpleNames,
+		"location":               f.location,
+		"allMessages":            f.allMessages,
+		"allEnums":               f.allEnums,
+		"enumGaps":               enumGaps,
+		"methodList":             f.methodList,
+		"serviceNav":             f.serviceNav,
+		"methodService":          methodService,
+		"markdown":               f.markdown,
+		"importClosure":          f.importClosure,
+		"jsonMappingNote":        f.jsonMappingNote,
+		"hasSourceInfo":          hasSourceInfo,
+		"optionsTable":           optionsTable,
+		"undocumented":           undocumented,
+		"jsonExample":            f.jsonExample,
+		"defaultValue":           f.defaultValue,
+		"streamingFlags":         methodStreaming,
+		"methodKind":             methodKind,
+		"pathParams":             f.pathParams,
+		"enumValueColor":         f.enumValueColor,
+		"fieldVisibility":        f.fieldVisibility,
+		"visibleFields":          f.visibleFields,
+		"fields":                 f.fields,
+		"enumValues":             f.enumValues,
+		"services":               f.services,
+		"packageOverview":        f.packageOverview,
+		"isForwardRef":           f.isForwardRef,
+		"estimatedSize":          estimatedSize,
+		"mapEntry":               f.mapEntry,
+		"fieldTypeLink":          f.fieldTypeLink,
+		"goImportPath":           goImportPath,
+		"goPackageAlias":         goPackageAlias,
+		"declAnchor":             f.declAnchor,
+		"streamingMethods":       f.streamingMethods,
+		"since":                  f.since,
+		"messageCard":            f.messageCard,
+		"isPacked":               f.isPacked,
+		"grpcPath":               f.grpcPath,
+		"fieldTable":             f.fieldTable,
+		"fieldRow":               f.fieldRow,
+		"allServices":            f.allServices,
+		"oneofs":                 f.oneofs,
+		"fieldDoc":               f.fieldDoc,
+		"isEmptyMessage":         f.isEmptyMessage,
+		"scalarTypeName":         f.scalarTypeName,
+		"reconstructProto":       f.reconstructProto,
+		"methods":                f.methods,
+		"definitionWithComments": f.definitionWithComments,
+		"oneofFields":            oneofFields,
+		"isSyntheticOneof":       isSyntheticOneof,
+		"mapType":                f.mapType,
+		"jsonString":             jsonString,
+		"crossPackageReferences": crossPackageReferences,
+		"fieldDefault":           f.fieldDefault,
+		"optionsSummary":         optionsSummary,
+		"methodAnchor":           f.methodAnchor,
+		"methodURL":              f.methodURL,
+		"clientExample":          f.clientExample,
+		"usesReservedNumber":     usesReservedNumber,
+		"inlineSubMessages":      f.inlineSubMessages,
+		"tableOfContents":        f.tableOfContents,
+		"qualifiedType":          f.qualifiedType,
+		"reservedRanges":         reservedRanges,
+		"reservedNames":          reservedNames,
+		"extensions":             f.extensions,
+		"isDeprecated":           isDeprecated,
+		"syntax":                 syntax,
+		"enumValueNumber":        enumValueNumber,
+		"enumAllowsAlias":        enumAllowsAlias,
+		"enumValueAliases":       enumValueAliases,
+		"customOptions":          customOptions,
+		"httpRules":              methodHTTPRules,
+		"jsonName":               jsonName,
+		"fieldNumber":            f.fieldNumber,
+		"comments":               comments,
+		"leadingComments":        f.leadingComments,
+		"trailingComments":       f.trailingComments,
+		"detachedComments":       f.detachedComments,
+		"fileComments":           fileComments,
+		"add":                    add,
+		"sub":                    sub,
+		"mul":                    mul,
+		"mod":                    mod,
+		"seq":                    seq,
+		"lower":                  strings.ToLower,
+		"upper":                  strings.ToUpper,
+		"title":                  title,
+		"replace":                replace,
+		"trimPrefix":             strings.TrimPrefix,
+		"hasPrefix":              strings.HasPrefix,
+		"fieldTypeFull":          f.fieldTypeFull,
+		"fieldLabel":             f.syntaxLabel,
 		"lastProtoFile": func() *descriptor.FileDescriptorProto {
 			return f.protoFiles[len(f.protoFiles)-1]
 		},
@@ -66,18 +209,40 @@ func (f *tmplFuncs) funcMap() template.FuncMap {
 }
 
 // labelString returns the clean (i.e. human-readable / protobuf-style) version
-// of a label.
+// of a label. It returns an empty string for a nil label, and "unknown" for a
+// value which is not a known label, so that an unexpected descriptor does not
+// stop the generation. Fields of proto3 files have the optional label even
+// when it is not written in the file, see syntaxLabel.
 func labelString(l *descriptor.FieldDescriptorProto_Label) string {
-	switch int32(*l) {
-	case 1:
+	if l == nil {
+		return ""
+	}
+	switch *l {
+	case descriptor.FieldDescriptorProto_LABEL_OPTIONAL:
 		return "optional"
-	case 2:
+	case descriptor.FieldDescriptorProto_LABEL_REQUIRED:
 		return "required"
-	case 3:
+	case descriptor.FieldDescriptorProto_LABEL_REPEATED:
 		return "repeated"
 	default:
-		panic("unknown label")
+		return "unknown"
+	}
+}
+
+// syntaxLabel returns the label of the field as it is written in the syntax of
+// its file, e.g. "repeated", or an empty string for a singular proto3 field,
+// which has no label unless it is declared optional.
+func (f *tmplFuncs) syntaxLabel(field *descriptor.FieldDescriptorProto) string {
+	return strings.TrimSpace(fieldLabel(f.fieldFile(field), field))
+}
+
+// syntax returns the syntax of the file, "proto2" or "proto3". Files without a
+// syntax are "proto2", like protoc treats them.
+func syntax(file *descriptor.FileDescriptorProto) string {
+	if file.GetSyntax() == "" {
+		return "proto2"
 	}
+	return file.GetSyntax()
 }
 
 // typeBaseName returns the last part of a types name, i.e. for a fully-qualified
@@ -96,32 +261,180 @@ func fieldType(field *descriptor.FieldDescriptorProto) string {
 	return util.FieldTypeName(field.Type)
 }
 
+// displayFieldType returns the type of the field as it is displayed in the
+// documentation. It is the same as fieldType, except scalar types are renamed
+// by Config.ScalarDisplayNames.
+func (f *tmplFuncs) displayFieldType(field *descriptor.FieldDescriptorProto) string {
+	if field.TypeName != nil {
+		return typeBaseName(*field.TypeName)
+	}
+	return f.scalarTypeName(field)
+}
+
+// scalarTypeName returns the display name of the scalar type of the field from
+// Config.ScalarDisplayNames, or the proto keyword of the type if it has no
+// display name. An empty string is returned for message and enum fields.
+func (f *tmplFuncs) scalarTypeName(field *descriptor.FieldDescriptorProto) string {
+	if field.TypeName != nil {
+		return ""
+	}
+	name := util.FieldTypeName(field.Type)
+	if display, ok := f.config.ScalarDisplayNames[name]; ok {
+		return display
+	}
+	return name
+}
+
 // typeURL returns a URL to the documentation file for the given type. The
 // input type path can be either fully-qualified or not, regardless, the URL
 // returned will always have a fully-qualified hash.
 //
-// TODO(slimsag): have the template pass in the relative type instead of nil,
-// so that relative symbol paths work.
-func (f *tmplFuncs) typeURL(symbolPath string) string {
+// A type path which is not fully-qualified is resolved relative to the
+// optional relative scope, which is either the fully-qualified path of the
+// enclosing message or the message itself, the same way protoc resolves it:
+// the innermost enclosing scope is searched first, then each outer scope.
+// Without a scope, the type is resolved relative to the package of the target
+// file. An empty string is returned if the type can not be resolved.
+//
+// When the operation documents all files in a single document, the URL of a
+// type declared in one of those files is a link within the document.
+//
+// Types from packages with external documentation, such as the well-known
+// types, link to that documentation unless they are declared in one of the
+// files being generated. See Config.ExternalTypeURLs.
+func (f *tmplFuncs) typeURL(symbolPath string, relative ...interface{}) string {
+	symbolPath = f.qualifyTypeName(symbolPath, relative...)
+	if symbolPath == "" {
+		return ""
+	}
 	_, file := util.NewResolver(f.protoFiles).Resolve(symbolPath, nil)
+	if file == nil || !f.isGenerated(file) {
+		if url := f.externalTypeURL(symbolPath); url != "" {
+			return url
+		}
+	}
 	if file == nil {
+		log.WithField("type", symbolPath).Debug("failed to resolve type")
 		return ""
 	}
+	if f.singleDocument && f.isGenerated(file) {
+		return "#" + f.anchor(symbolPath, file)
+	}
 	pkgPath := file.GetName()
 
+	// Prefix the absolute path with the root directory and swap the extension out
+	// with the correct one.
+	p := f.config.pageName(pkgPath) + path.Ext(f.outputFile)
+	p = path.Join(f.linkRoot(file), p)
+	return fmt.Sprintf("%s#%s", p, f.anchor(symbolPath, file))
+}
+
+// qualifyTypeName returns the fully-qualified path of the type symbolPath
+// resolved relative to the scope, see typeURL, or an empty string if it can not
+// be resolved. A scope path without the leading "." is treated as
+// fully-qualified, e.g. "foo.Outer" is the same scope as ".foo.Outer".
+func (f *tmplFuncs) qualifyTypeName(symbolPath string, relative ...interface{}) string {
+	if symbolPath == "" || strings.HasPrefix(symbolPath, ".") {
+		return symbolPath
+	}
+
+	var scope string
+	if len(relative) > 0 {
+		switch v := relative[0].(type) {
+		case string:
+			scope = strings.TrimSuffix(v, ".")
+			if scope != "" && !strings.HasPrefix(scope, ".") {
+				scope = "." + scope
+			}
+		case *descriptor.DescriptorProto:
+			scope = f.messageFullName(v)
+			if scope == "" && f.protoFileDescriptor != nil {
+				scope = util.FullName(f.protoFileDescriptor, v.GetName())
+			}
+		}
+	} else if f.protoFileDescriptor != nil && f.protoFileDescriptor.GetPackage() != "" {
+		scope = "." + f.protoFileDescriptor.GetPackage()
+	}
+
+	resolver := util.NewResolver(f.protoFiles)
+	for {
+		// The scope is empty or starts with ".", so the candidate is always
+		// fully-qualified, which is required by Resolve.
+		candidate := scope + "." + symbolPath
+		if node, _ := resolver.Resolve(candidate, nil); node != nil {
+			return candidate
+		}
+		if scope == "" {
+			return ""
+		}
+		scope = util.TrimElem(scope, -1)
+	}
+}
+
+// typeAnchor returns the anchor which should be used for the heading of the
+// type, so that links returned by typeURL for the type target the heading.
+func (f *tmplFuncs) typeAnchor(symbolPath string) string {
+	_, file := util.NewResolver(f.protoFiles).Resolve(symbolPath, nil)
+	if file == nil {
+		return ""
+	}
+	return f.anchor(symbolPath, file)
+}
+
+// declAnchor returns the anchor which should be used for the heading of the
+// type declared in file with the name, which is parent-qualified for nested
+// types (e.g. "Outer.Inner", as returned by allMessages). It always matches
+// the fragment of the URL returned by typeURL for the type.
+func (f *tmplFuncs) declAnchor(file *descriptor.FileDescriptorProto, name string) string {
+	return f.anchor(util.FullName(file, name), file)
+}
+
+// anchor returns the anchor of the type declared in file. When
+// Config.SlugAnchors is set the anchor is slugged, see slugAnchor.
+func (f *tmplFuncs) anchor(symbolPath string, file *descriptor.FileDescriptorProto) string {
+	if f.config.SlugAnchors {
+		return f.slugAnchor(symbolPath, file)
+	}
+	return f.plainAnchor(symbolPath, file)
+}
+
+// plainAnchor returns the anchor of the type declared in file, which is the
+// name of the type relative to its package.
+func (f *tmplFuncs) plainAnchor(symbolPath string, file *descriptor.FileDescriptorProto) string {
+	if f.singleDocument {
+		// Types from different packages share a page, so the package is kept
+		// in the anchor.
+		return strings.TrimPrefix(symbolPath, ".")
+	}
+
 	// Remove the package prefix from types, for example:
 	//
 	//  pkg.html#.pkg.Type.SubType
 	//  ->
 	//  pkg.html#Type.SubType
 	//
-	typePath := util.TrimElem(symbolPath, util.CountElem(file.GetPackage()))
+	return util.TrimElem(symbolPath, util.CountElem(file.GetPackage()))
+}
 
-	// Prefix the absolute path with the root directory and swap the extension out
-	// with the correct one.
-	p := trimExt(pkgPath) + path.Ext(f.outputFile)
-	p = path.Join(f.urlRoot, p)
-	return fmt.Sprintf("%s#%s", p, typePath)
+// linkRoot returns the URL root of links to the page of file. The URL root of
+// the operation is used for files in the package of the target, and
+// Config.URLRoot for files in other packages, which are not affected by the
+// OperationConfig.URLRoot of the operation.
+func (f *tmplFuncs) linkRoot(file *descriptor.FileDescriptorProto) string {
+	if f.protoFileDescriptor != nil && file.GetPackage() != f.protoFileDescriptor.GetPackage() {
+		return f.config.URLRoot
+	}
+	return f.urlRoot
+}
+
+// isGenerated returns true if file is one of the files being generated.
+func (f *tmplFuncs) isGenerated(file *descriptor.FileDescriptorProto) bool {
+	for _, v := range f.files {
+		if v == file {
+			return true
+		}
+	}
+	return false
 }
 
 // location returns the source code info location for the generic AST-like node
@@ -135,28 +448,36 @@ func (f *tmplFuncs) location(x interface{}) *descriptor.SourceCodeInfo_Location
 		panic("expected descriptor type; got " + fmt.Sprintf("%q", pkgPath))
 	}
 
-	// If the location cache is empty; we build it now.
-	if f.locCache == nil {
-		for _, loc := range f.protoFileDescriptor.SourceCodeInfo.Location {
-			f.locCache = append(f.locCache, cacheItem{
-				V: walkPath(loc.Path, f.protoFileDescriptor),
-				L: loc,
-			})
-		}
+	if !isNodeKey(x) {
+		return nil
 	}
-	return f.findCachedItem(x)
-}
-
-// findCachedItem finds and returns a cached location for x.
-func (f *tmplFuncs) findCachedItem(x interface{}) *descriptor.SourceCodeInfo_Location {
-	for _, i := range f.locCache {
-		if i.V == x {
-			return i.L
+	if f.locations == nil {
+		f.locations = newLocationCache()
+	}
+	files := []*descriptor.FileDescriptorProto{f.protoFileDescriptor}
+	if f.singleDocument || f.protoFileDescriptor == nil {
+		files = f.files
+	}
+	for _, file := range files {
+		if loc, ok := f.locations.file(file)[x]; ok {
+			return loc
 		}
 	}
+	// The copies of nested types returned by allMessages and allEnums have
+	// the location of the declaration they were copied from.
+	if original := f.declaration(x); original != x {
+		return f.location(original)
+	}
 	return nil
 }
 
+// hasSourceInfo returns true if the file has source code info, which is only
+// included by protoc when it is run with source info, and is required for
+// rendering comments.
+func hasSourceInfo(file *descriptor.FileDescriptorProto) bool {
+	return len(file.GetSourceCodeInfo().GetLocation()) > 0
+}
+
 // walkPath walks through the root node (the protoFileDescriptor.protoFileDescriptor file) descending down the path
 // until it is resolved, at which point the value is returned.
 func walkPath(path []int32, protoFileDescriptor *descriptor.FileDescriptorProto) interface{} {
@@ -1,6 +1,14 @@
 package tmpl
 
-import "testing"
+import (
+	"bytes"
+	"html/template"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
 
 func TestStripExt(t *testing.T) {
 	var files = map[string]string{
@@ -15,3 +23,249 @@ func TestStripExt(t *testing.T) {
 		}
 	}
 }
+
+func TestHasSourceInfo(t *testing.T) {
+	without := &descriptor.FileDescriptorProto{Name: proto.String("foo.proto")}
+	if hasSourceInfo(without) {
+		t.Fatal("expected no source info")
+	}
+	empty := &descriptor.FileDescriptorProto{
+		Name:           proto.String("foo.proto"),
+		SourceCodeInfo: &descriptor.SourceCodeInfo{},
+	}
+	if hasSourceInfo(empty) {
+		t.Fatal("expected no source info for empty source code info")
+	}
+	with := &descriptor.FileDescriptorProto{
+		Name: proto.String("foo.proto"),
+		SourceCodeInfo: &descriptor.SourceCodeInfo{
+			Location: []*descriptor.SourceCodeInfo_Location{
+				{Path: []int32{4, 0}, Span: []int32{1, 0, 3, 1}},
+			},
+		},
+	}
+	if !hasSourceInfo(with) {
+		t.Fatal("expected source info")
+	}
+}
+
+func TestSlug(t *testing.T) {
+	var tests = map[string]string{
+		"My Service v1":  "my-service-v1",
+		"foo_bar":        "foo-bar",
+		"  --Leading--":  "leading",
+		"Outer.Inner":    "outer-inner",
+		"already-a-slug": "already-a-slug",
+	}
+	for s, expected := range tests {
+		if got := slug(s); got != expected {
+			t.Fatalf("got %q expected %q", got, expected)
+		}
+	}
+}
+
+func TestPagePathMatchesTypeURL(t *testing.T) {
+	file := &descriptor.FileDescriptorProto{
+		Name:    proto.String("api/My Service_v1.proto"),
+		Package: proto.String("api"),
+		MessageType: []*descriptor.DescriptorProto{
+			{Name: proto.String("Foo")},
+		},
+	}
+	config := Config{SlugPageNames: true}
+	f := &tmplFuncs{
+		outputFile: "index.html",
+		protoFiles: []*descriptor.FileDescriptorProto{file},
+		config:     config,
+	}
+	request := newTestRequest(file)
+	output := defaultOperations(request, config)[1].Output
+	if output != "api/my-service-v1.html" {
+		t.Fatalf("got %q expected %q", output, "api/my-service-v1.html")
+	}
+	if got := f.typeURL(".api.Foo"); got != output+"#Foo" {
+		t.Fatalf("got %q expected %q", got, output+"#Foo")
+	}
+}
+
+func TestScalarDisplayNames(t *testing.T) {
+	f := &tmplFuncs{config: Config{ScalarDisplayNames: map[string]string{"bool": "yes/no"}}}
+	enabled := &descriptor.FieldDescriptorProto{
+		Name: proto.String("enabled"),
+		Type: descriptor.FieldDescriptorProto_TYPE_BOOL.Enum(),
+	}
+	count := &descriptor.FieldDescriptorProto{
+		Name: proto.String("count"),
+		Type: descriptor.FieldDescriptorProto_TYPE_INT32.Enum(),
+	}
+
+	if got := f.displayFieldType(enabled); got != "yes/no" {
+		t.Fatalf("got %q expected %q", got, "yes/no")
+	}
+	if got := f.scalarTypeName(count); got != "int32" {
+		t.Fatalf("got %q expected %q", got, "int32")
+	}
+	// The type of the field is unchanged.
+	if got := fieldType(enabled); got != "bool" {
+		t.Fatalf("got %q expected %q", got, "bool")
+	}
+	if got := f.jsonExample(&descriptor.DescriptorProto{
+		Field: []*descriptor.FieldDescriptorProto{enabled},
+	}); !strings.Contains(got, "false") {
+		t.Fatalf("expected example %q to contain a bool value", got)
+	}
+}
+
+func TestTypeURLRelative(t *testing.T) {
+	file := &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo.v1"),
+		MessageType: []*descriptor.DescriptorProto{
+			{
+				Name: proto.String("Outer"),
+				NestedType: []*descriptor.DescriptorProto{
+					{Name: proto.String("Inner")},
+					{Name: proto.String("Sibling")},
+				},
+			},
+			{Name: proto.String("Inner")},
+		},
+	}
+	f := &tmplFuncs{
+		protoFileDescriptor: file,
+		outputFile:          "foo.html",
+		protoFiles:          []*descriptor.FileDescriptorProto{file},
+	}
+	outer := file.MessageType[0]
+	sibling := outer.NestedType[1]
+
+	var testCases = []struct {
+		doc        string
+		symbolPath string
+		relative   []interface{}
+		expected   string
+	}{
+		{doc: "scope path", symbolPath: "Inner", relative: []interface{}{".foo.v1.Outer"}, expected: "foo.html#Outer.Inner"},
+		{doc: "scope message", symbolPath: "Inner", relative: []interface{}{outer}, expected: "foo.html#Outer.Inner"},
+		{doc: "outer scope", symbolPath: "Inner", relative: []interface{}{sibling}, expected: "foo.html#Outer.Inner"},
+		{doc: "target package", symbolPath: "Inner", expected: "foo.html#Inner"},
+		{doc: "partially qualified", symbolPath: "v1.Outer.Sibling", expected: "foo.html#Outer.Sibling"},
+		{doc: "fully-qualified", symbolPath: ".foo.v1.Inner", relative: []interface{}{outer}, expected: "foo.html#Inner"},
+		{doc: "missing", symbolPath: "Missing", relative: []interface{}{outer}, expected: ""},
+		{doc: "empty", symbolPath: "", expected: ""},
+		{doc: "undotted scope", symbolPath: "Inner", relative: []interface{}{"foo.v1.Outer"}, expected: "foo.html#Outer.Inner"},
+		{doc: "missing in undotted scope", symbolPath: "Missing", relative: []interface{}{"foo.v1.Outer"}, expected: ""},
+		{doc: "missing in unknown scope", symbolPath: "Missing.Type", relative: []interface{}{"other"}, expected: ""},
+	}
+	for _, testCase := range testCases {
+		if got := f.typeURL(testCase.symbolPath, testCase.relative...); got != testCase.expected {
+			t.Errorf("%s: got %q expected %q", testCase.doc, got, testCase.expected)
+		}
+	}
+}
+
+func TestSyntax(t *testing.T) {
+	var testCases = map[string]string{
+		"":       "proto2",
+		"proto2": "proto2",
+		"proto3": "proto3",
+	}
+	for value, expected := range testCases {
+		file := &descriptor.FileDescriptorProto{Syntax: proto.String(value)}
+		if got := syntax(file); got != expected {
+			t.Errorf("%q: got %q expected %q", value, got, expected)
+		}
+	}
+	if got := syntax(&descriptor.FileDescriptorProto{}); got != "proto2" {
+		t.Errorf("expected a file without syntax to be proto2, got %q", got)
+	}
+}
+
+func TestSyntaxInTemplate(t *testing.T) {
+	tmpl := template.Must(template.New("syntax").Funcs(newDefaultTemplateFuncs()).Parse(
+		`{{if eq (syntax .) "proto3"}}proto3 badge{{else}}no badge{{end}}`))
+	buf := new(bytes.Buffer)
+	if err := tmpl.Execute(buf, &descriptor.FileDescriptorProto{Syntax: proto.String("proto3")}); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "proto3 badge" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestLabelString(t *testing.T) {
+	unexpected := descriptor.FieldDescriptorProto_Label(9)
+	var tests = []struct {
+		label    *descriptor.FieldDescriptorProto_Label
+		expected string
+	}{
+		{nil, ""},
+		{&unexpected, "unknown"},
+		{descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(), "optional"},
+		{descriptor.FieldDescriptorProto_LABEL_REQUIRED.Enum(), "required"},
+		{descriptor.FieldDescriptorProto_LABEL_REPEATED.Enum(), "repeated"},
+	}
+	for _, test := range tests {
+		if got := labelString(test.label); got != test.expected {
+			t.Errorf("%v: got %q expected %q", test.label, got, test.expected)
+		}
+	}
+}
+
+func TestSyntaxLabel(t *testing.T) {
+	singular := &descriptor.FieldDescriptorProto{
+		Name:  proto.String("name"),
+		Label: descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:  descriptor.FieldDescriptorProto_TYPE_STRING.Enum(),
+	}
+	repeated := &descriptor.FieldDescriptorProto{
+		Name:  proto.String("tags"),
+		Label: descriptor.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+		Type:  descriptor.FieldDescriptorProto_TYPE_STRING.Enum(),
+	}
+	noLabel := &descriptor.FieldDescriptorProto{Name: proto.String("raw")}
+	file := &descriptor.FileDescriptorProto{
+		Name: proto.String("foo.proto"),
+		MessageType: []*descriptor.DescriptorProto{{
+			Name:  proto.String("Foo"),
+			Field: []*descriptor.FieldDescriptorProto{singular, repeated, noLabel},
+		}},
+	}
+	f := &tmplFuncs{protoFileDescriptor: file, protoFiles: []*descriptor.FileDescriptorProto{file}}
+
+	var tests = []struct {
+		syntax   string
+		field    *descriptor.FieldDescriptorProto
+		expected string
+	}{
+		{"proto3", singular, ""},
+		{"proto3", repeated, "repeated"},
+		{"proto3", noLabel, ""},
+		{"proto2", singular, "optional"},
+		{"proto2", repeated, "repeated"},
+		{"proto2", noLabel, ""},
+	}
+	for _, test := range tests {
+		file.Syntax = proto.String(test.syntax)
+		if got := f.syntaxLabel(test.field); got != test.expected {
+			t.Errorf("%s %s: got %q expected %q", test.syntax, test.field.GetName(), got, test.expected)
+		}
+	}
+}
+
+func TestTypeURLRelativeWithoutPackage(t *testing.T) {
+	file := &descriptor.FileDescriptorProto{
+		Name:        proto.String("foo.proto"),
+		MessageType: []*descriptor.DescriptorProto{{Name: proto.String("Outer")}},
+	}
+	f := &tmplFuncs{
+		protoFileDescriptor: file,
+		outputFile:          "foo.html",
+		protoFiles:          []*descriptor.FileDescriptorProto{file},
+	}
+	for _, relative := range [][]interface{}{nil, {"Outer"}, {""}, {file.MessageType[0]}} {
+		if got := f.typeURL("Missing", relative...); got != "" {
+			t.Errorf("%v: got %q expected no URL", relative, got)
+		}
+	}
+}
@@ -2,27 +2,44 @@ package tmpl
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"fmt"
 	"html/template"
+	"io"
+	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	texttemplate "text/template"
 
 	gateway "github.com/gengo/grpc-gateway/protoc-gen-grpc-gateway/descriptor"
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/protoc-gen-go/descriptor"
 	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 )
 
 type generator struct {
-	config  Config
-	request *plugin.CodeGeneratorRequest
+	config    Config
+	request   *plugin.CodeGeneratorRequest
+	locations *locationCache
 }
 
-// New returns a new generator for the given template.
+// Generate executes the operations in config for the request and returns the
+// generated files. It does not read or write any files other than the
+// templates, so it can be called directly by programs which embed the
+// generator instead of running it as a protoc plugin. WriteFiles can be used to
+// write the response to disk.
 func Generate(request *plugin.CodeGeneratorRequest, config Config) (*plugin.CodeGeneratorResponse, error) {
 	if len(request.FileToGenerate) == 0 {
 		return nil, errors.New("no input files")
 	}
+	if err := config.Validate(request); err != nil {
+		return nil, err
+	}
 
 	registry := gateway.NewRegistry()
 	err := registry.Load(request)
@@ -30,34 +47,114 @@ func Generate(request *plugin.CodeGeneratorRequest, config Config) (*plugin.Code
 		return nil, errors.Wrapf(err, "failed to load request")
 	}
 
-	g := &generator{request: request, config: config}
+	g := &generator{request: request, config: config, locations: newLocationCache()}
 	return g.Generate(), nil
 }
 
 func (g *generator) Generate() *plugin.CodeGeneratorResponse {
 	if len(g.config.Operations) == 0 {
-		g.config.Operations = defaultOperations(g.request)
+		g.config.Operations = defaultOperations(g.request, g.config)
 	}
 
 	response := &plugin.CodeGeneratorResponse{}
 	errs := new(bytes.Buffer)
-	for _, opConfig := range g.config.Operations {
-		f, err := g.genTarget(opConfig)
-		if err != nil {
-			errs.WriteString(fmt.Sprintf("%s\n", err))
+	for _, result := range g.genTargets() {
+		if result.err != nil {
+			errs.WriteString(fmt.Sprintf("%s\n", result.err))
 			continue
 		}
-		response.File = append(response.File, f)
+		if result.file == nil {
+			continue // skipped because it is empty
+		}
+		response.File = append(response.File, result.file)
 	}
+	sort.SliceStable(response.File, func(i, j int) bool {
+		return response.File[i].GetName() < response.File[j].GetName()
+	})
 
+	if errs.Len() == 0 && len(response.File) == 0 && g.config.FailOnNoOutput {
+		errs.WriteString("no files were generated\n")
+	}
 	if errs.Len() > 0 {
 		response.File = nil
 		response.Error = proto.String(errs.String())
+		return response
+	}
+	if g.config.Checksums {
+		response.File = append(response.File, checksums(response.File))
 	}
 	return response
 }
 
-func defaultOperations(request *plugin.CodeGeneratorRequest) []OperationConfig {
+// genResult is the result of generating the target of one operation.
+type genResult struct {
+	op   OperationConfig
+	file *plugin.CodeGeneratorResponse_File
+	err  error
+}
+
+// genTargets generates the targets of all the operations, after expanding
+// Target patterns, with up to GOMAXPROCS operations generated concurrently.
+// The results are returned in the order of the operations. Each target has its
+// own tmplFuncs, so the generator is only read by the workers.
+func (g *generator) genTargets() []genResult {
+	var results []genResult
+	for _, opConfig := range g.config.Operations {
+		ops, err := g.expandTarget(opConfig)
+		if err != nil {
+			results = append(results, genResult{op: opConfig, err: err})
+			continue
+		}
+		for _, op := range ops {
+			results = append(results, genResult{op: op})
+		}
+	}
+
+	pending := make(chan *genResult)
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.GOMAXPROCS(0); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for result := range pending {
+				logger := log.WithField("target", result.op.Target).WithField("output", result.op.Output)
+				logger.Debug("generating operation")
+				result.file, result.err = g.genTarget(result.op)
+				if result.err != nil {
+					logger = logger.WithError(result.err)
+				}
+				logger.Debug("finished operation")
+			}
+		}()
+	}
+	for i := range results {
+		if results[i].err == nil {
+			pending <- &results[i]
+		}
+	}
+	close(pending)
+	wg.Wait()
+	return results
+}
+
+// checksumsFile is the name of the output file written when Config.Checksums
+// is enabled.
+const checksumsFile = "checksums.txt"
+
+// checksums returns a file which lists the SHA-256 of the content of each of
+// the files, in the format of sha256sum.
+func checksums(files []*plugin.CodeGeneratorResponse_File) *plugin.CodeGeneratorResponse_File {
+	buf := new(bytes.Buffer)
+	for _, f := range files {
+		fmt.Fprintf(buf, "%x  %s\n", sha256.Sum256([]byte(f.GetContent())), f.GetName())
+	}
+	return &plugin.CodeGeneratorResponse_File{
+		Name:    proto.String(checksumsFile),
+		Content: proto.String(buf.String()),
+	}
+}
+
+func defaultOperations(request *plugin.CodeGeneratorRequest, config Config) []OperationConfig {
 	ops := []OperationConfig{
 		{
 			Template: "index.fragment.html",
@@ -68,16 +165,29 @@ func defaultOperations(request *plugin.CodeGeneratorRequest) []OperationConfig {
 		op := OperationConfig{
 			Template: "template.html",
 			Target:   *protoFile.Name,
-			Output:   fmt.Sprintf("%s.html", trimExt(*protoFile.Name)),
+			Output:   fmt.Sprintf("%s.html", config.pageName(*protoFile.Name)),
 		}
 		ops = append(ops, op)
 	}
 	return ops
 }
 
+const (
+	modeSingle = "single"
+	modeIndex  = "index"
+)
+
 type templateContext struct {
 	*plugin.CodeGeneratorRequest
 	Target *descriptor.FileDescriptorProto
+	// Files are the files being generated.
+	Files []*descriptor.FileDescriptorProto
+	// Outputs are the output paths of the operations which are not in the
+	// "index" mode, for linking to every page from an index.
+	Outputs []string
+	// Error is the error from the primary template when rendering a fallback
+	// template.
+	Error string
 }
 
 func (g *generator) genTarget(opConfig OperationConfig) (*plugin.CodeGeneratorResponse_File, error) {
@@ -86,31 +196,204 @@ func (g *generator) genTarget(opConfig OperationConfig) (*plugin.CodeGeneratorRe
 		return nil, errors.Errorf("no input proto file for generator target %q", opConfig.Target)
 	}
 
+	var content string
+	var err error
+	switch opConfig.Format {
+	case "":
+		content, err = g.render(opConfig, protoFile, "")
+		if err != nil && opConfig.Fallback != "" {
+			content, err = g.renderFallback(opConfig, protoFile, err)
+		}
+	case formatManifest:
+		content, err = g.genManifest(opConfig)
+	case formatBundle:
+		content, err = g.genBundle(opConfig)
+	case formatGRPCRef:
+		content = g.genGRPCRef()
+	case formatSearch:
+		content, err = g.genSearchIndex(opConfig)
+	default:
+		err = errors.Errorf("unknown format %q", opConfig.Format)
+	}
+	if err == nil && opConfig.Wrap != "" {
+		content, err = g.wrap(opConfig, protoFile, content)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if g.config.SkipEmpty && strings.TrimSpace(content) == "" {
+		return nil, nil
+	}
+	if g.config.Canonical {
+		content = normalizeWhitespace(content)
+	}
+
+	return &plugin.CodeGeneratorResponse_File{
+		Name:    proto.String(opConfig.Output),
+		Content: proto.String(insertPreamble(preamble(g.config.Preamble, opConfig.Output), content)),
+	}, nil
+}
+
+// renderFallback executes the fallback template of the operation after the
+// primary template failed with renderErr.
+func (g *generator) renderFallback(opConfig OperationConfig, protoFile *descriptor.FileDescriptorProto, renderErr error) (string, error) {
+	opConfig.Template = opConfig.Fallback
+	content, err := g.render(opConfig, protoFile, renderErr.Error())
+	if err != nil {
+		return "", errors.Wrapf(err, "fallback failed after error: %s", renderErr)
+	}
+	return content, nil
+}
+
+// render executes the template of the operation for the target protoFile.
+// renderErr is the error passed to fallback templates.
+func (g *generator) render(opConfig OperationConfig, protoFile *descriptor.FileDescriptorProto, renderErr string) (string, error) {
 	tmpl, err := g.loadTemplate(opConfig)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to load template %s", opConfig.Template)
+		return "", errors.Wrapf(err, "failed to load template %s", opConfig.Template)
 	}
 
 	buf := new(bytes.Buffer)
-	funcs := &tmplFuncs{
+	funcs := g.newFuncs(opConfig, protoFile)
+	ctx := templateContext{
+		CodeGeneratorRequest: g.request,
+		Target:               protoFile,
+		Files:                funcs.files,
+		Outputs:              g.outputs(),
+		Error:                renderErr,
+	}
+	err = execute(tmpl.Funcs(funcs.funcMap()), buf, ctx)
+	if err != nil {
+		return "", renderError(opConfig, opConfig.Template, err)
+	}
+	return buf.String(), nil
+}
+
+// execute executes the template, and returns an error instead of panicking if
+// the template panics.
+func execute(tmpl *template.Template, w io.Writer, data interface{}) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("template %s panicked: %v", tmpl.Name(), r)
+		}
+	}()
+	return tmpl.Execute(w, data)
+}
+
+// renderError returns err from executing the template name for the operation,
+// with the operation and the position in the template of the action which
+// failed.
+func renderError(opConfig OperationConfig, name string, err error) error {
+	if name == "" {
+		name = "builtin"
+	}
+	if pos := execPosition(err); pos != "" {
+		name += " at " + pos
+	}
+	return errors.Wrapf(err, "failed to render template %s for target %q to %q",
+		name, opConfig.Target, opConfig.Output)
+}
+
+// execPosition returns the name of the template and the line and column of the
+// action of an execution error, e.g. "target.html:3:12", or an empty string
+// if err is not an execution error.
+func execPosition(err error) string {
+	execErr, ok := err.(texttemplate.ExecError)
+	if !ok {
+		return ""
+	}
+	// The position is only available from the message, which is
+	// "template: <name>:<line>:<col>: ...".
+	msg := strings.TrimPrefix(execErr.Error(), "template: ")
+	if !strings.HasPrefix(msg, execErr.Name+":") {
+		return ""
+	}
+	parts := strings.SplitN(msg[len(execErr.Name)+1:], ":", 3)
+	if len(parts) < 3 {
+		return ""
+	}
+	return fmt.Sprintf("%s:%s:%s", execErr.Name, parts[0], parts[1])
+}
+
+// newFuncs returns the template functions for rendering the operation for the
+// target protoFile.
+func (g *generator) newFuncs(opConfig OperationConfig, protoFile *descriptor.FileDescriptorProto) *tmplFuncs {
+	return &tmplFuncs{
 		protoFileDescriptor: protoFile,
 		outputFile:          opConfig.Output,
-		urlRoot:             g.config.URLRoot,
+		urlRoot:             g.urlRoot(opConfig),
 		protoFiles:          g.request.GetProtoFile(),
+		files:               g.filesToGenerate(),
+		singleDocument:      opConfig.Mode == modeSingle,
+		locations:           g.locations,
+		config:              g.config,
 	}
-	ctx := templateContext{
-		CodeGeneratorRequest: g.request,
-		Target:               protoFile,
+}
+
+// urlRoot returns the URL root of the links generated by the operation, which
+// is the URLRoot of the operation if it is set, otherwise Config.URLRoot.
+func (g *generator) urlRoot(opConfig OperationConfig) string {
+	if opConfig.URLRoot != "" {
+		return opConfig.URLRoot
 	}
-	err = tmpl.Funcs(funcs.funcMap()).Execute(buf, ctx)
+	return g.config.URLRoot
+}
+
+// wrapContext is the context of the Wrap template of an operation.
+type wrapContext struct {
+	// Body is the rendered output of the operation.
+	Body   template.HTML
+	Target *descriptor.FileDescriptorProto
+	Output string
+}
+
+// wrap executes the Wrap template of the operation with the rendered body.
+func (g *generator) wrap(opConfig OperationConfig, protoFile *descriptor.FileDescriptorProto, body string) (string, error) {
+	opConfig.Template = opConfig.Wrap
+	tmpl, err := g.loadTemplate(opConfig)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to render template")
+		return "", errors.Wrapf(err, "failed to load wrap template %s", opConfig.Wrap)
 	}
 
-	return &plugin.CodeGeneratorResponse_File{
-		Name:    proto.String(opConfig.Output),
-		Content: proto.String(buf.String()),
-	}, nil
+	buf := new(bytes.Buffer)
+	ctx := wrapContext{
+		Body:   template.HTML(body),
+		Target: protoFile,
+		Output: opConfig.Output,
+	}
+	err = execute(tmpl.Funcs(g.newFuncs(opConfig, protoFile).funcMap()), buf, ctx)
+	if err != nil {
+		return "", errors.Wrap(renderError(opConfig, opConfig.Wrap, err), "wrap failed")
+	}
+	return buf.String(), nil
+}
+
+// outputs returns the output paths of the operations which are not in the
+// "index" mode, in the order of the operations. Operations with a Target
+// pattern which can not be expanded are omitted.
+func (g *generator) outputs() []string {
+	var outputs []string
+	for _, opConfig := range g.config.Operations {
+		if opConfig.Mode == modeIndex {
+			continue
+		}
+		ops, _ := g.expandTarget(opConfig)
+		for _, op := range ops {
+			outputs = append(outputs, op.Output)
+		}
+	}
+	return outputs
+}
+
+// filesToGenerate returns the descriptors of the files being generated.
+func (g *generator) filesToGenerate() []*descriptor.FileDescriptorProto {
+	var files []*descriptor.FileDescriptorProto
+	for _, name := range g.request.GetFileToGenerate() {
+		if file := getProtoFileFromTarget(name, g.request); file != nil {
+			files = append(files, file)
+		}
+	}
+	return files
 }
 
 func getProtoFileFromTarget(target string, request *plugin.CodeGeneratorRequest) *descriptor.FileDescriptorProto {
@@ -123,10 +406,53 @@ func getProtoFileFromTarget(target string, request *plugin.CodeGeneratorRequest)
 }
 
 func (g *generator) loadTemplate(opConfig OperationConfig) (*template.Template, error) {
-	fullPath := filepath.Join(g.config.TemplateRoot, opConfig.Template)
-	tmpl, err := template.New("main").Funcs(newDefaultTemplateFuncs()).ParseFiles(fullPath)
+	var err error
+	tmpl := template.New("main").
+		Delims(opConfig.LeftDelim, opConfig.RightDelim).
+		Funcs(newDefaultTemplateFuncs()).
+		Funcs(g.config.Funcs)
+	for _, pattern := range g.config.Partials {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(g.config.TemplateRoot, pattern)
+		}
+		if tmpl, err = tmpl.ParseGlob(pattern); err != nil {
+			return nil, errors.Wrapf(err, "failed to load partials")
+		}
+	}
+	if opConfig.Template == "" {
+		log.WithField("builtin", g.config.BuiltinTemplate).Debug("loading builtin template")
+		return g.parseBuiltin(tmpl)
+	}
+
+	fullPath, err := g.templatePath(opConfig)
+	if err != nil {
+		return nil, err
+	}
+	log.WithField("template", fullPath).Debug("loading template")
+	// The template is parsed last so that its definitions replace any with the
+	// same name from the partials.
+	tmpl, err = tmpl.ParseFiles(fullPath)
 	if err != nil {
 		return nil, err
 	}
 	return tmpl.Lookup(filepath.Base(fullPath)), nil
 }
+
+// templatePath returns the absolute path of the template for the operation.
+// Relative templates are resolved against the TemplateRoot so that generation
+// does not depend on the working directory of the process.
+func (g *generator) templatePath(opConfig OperationConfig) (string, error) {
+	fullPath := opConfig.Template
+	if !filepath.IsAbs(fullPath) {
+		fullPath = filepath.Join(g.config.TemplateRoot, fullPath)
+	}
+	fullPath, err := filepath.Abs(fullPath)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(fullPath); err != nil {
+		return "", errors.Errorf("template %s does not exist in template root %q",
+			opConfig.Template, g.config.TemplateRoot)
+	}
+	return fullPath, nil
+}
@@ -0,0 +1,605 @@
+package tmpl
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
+)
+
+// newTestRequest returns a request which generates the first of the files.
+func newTestRequest(files ...*descriptor.FileDescriptorProto) *plugin.CodeGeneratorRequest {
+	return &plugin.CodeGeneratorRequest{
+		FileToGenerate: []string{files[0].GetName()},
+		ProtoFile:      files,
+	}
+}
+
+// newTestFuncs returns the funcs of the template which renders the first of the
+// files to foo.html, with the config.
+func newTestFuncs(config Config, files ...*descriptor.FileDescriptorProto) *tmplFuncs {
+	return &tmplFuncs{
+		protoFileDescriptor: files[0],
+		outputFile:          "foo.html",
+		protoFiles:          files,
+		config:              config,
+	}
+}
+
+func testdataRoot(t *testing.T) string {
+	root, err := filepath.Abs("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestGenerateFromOtherWorkingDir(t *testing.T) {
+	root := testdataRoot(t)
+	request := newTestRequest(&descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+	})
+	config := Config{
+		TemplateRoot: root,
+		Operations: []OperationConfig{
+			{Template: "target.html", Target: "foo.proto", Output: "foo.html"},
+		},
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "proto-gen-html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(cwd)
+
+	response, err := Generate(request, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Error != nil {
+		t.Fatalf("unexpected error: %s", response.GetError())
+	}
+	if got := response.File[0].GetContent(); got != "foo.proto\n" {
+		t.Fatalf("got %q expected %q", got, "foo.proto\n")
+	}
+}
+
+func TestGenerateMissingTemplate(t *testing.T) {
+	request := newTestRequest(&descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+	})
+	config := Config{
+		TemplateRoot: testdataRoot(t),
+		Operations: []OperationConfig{
+			{Template: "missing.html", Output: "foo.html"},
+		},
+	}
+	_, err := Generate(request, config)
+	if err == nil || !strings.Contains(err.Error(), "template missing.html does not exist") {
+		t.Fatalf("expected an error for a missing template, got %v", err)
+	}
+}
+
+func TestGenerateSingleAndPerFileOperations(t *testing.T) {
+	request := newTestRequest(&descriptor.FileDescriptorProto{
+		Name:    proto.String("foo/foo.proto"),
+		Package: proto.String("foo"),
+		MessageType: []*descriptor.DescriptorProto{
+			{Name: proto.String("Bar")},
+		},
+	})
+	config := Config{
+		TemplateRoot: testdataRoot(t),
+		Operations: []OperationConfig{
+			{Template: "single.html", Mode: "single", Output: "all.html"},
+			{Template: "target_messages.html", Target: "foo/foo.proto", Output: "foo/foo.html"},
+		},
+	}
+	response, err := Generate(request, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Error != nil {
+		t.Fatalf("unexpected error: %s", response.GetError())
+	}
+	if len(response.File) != 2 {
+		t.Fatalf("expected 2 files, got %d", len(response.File))
+	}
+
+	single := response.File[0].GetContent()
+	for _, expected := range []string{`id="foo.Bar"`, `href="#foo.Bar"`} {
+		if !strings.Contains(single, expected) {
+			t.Fatalf("expected %q to contain %q", single, expected)
+		}
+	}
+	perFile := response.File[1].GetContent()
+	for _, expected := range []string{`id="Bar"`, `href="foo/foo.html#Bar"`} {
+		if !strings.Contains(perFile, expected) {
+			t.Fatalf("expected %q to contain %q", perFile, expected)
+		}
+	}
+}
+
+func TestGenerateFallbackTemplate(t *testing.T) {
+	request := newTestRequest(&descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+	})
+	config := Config{
+		TemplateRoot: testdataRoot(t),
+		Operations: []OperationConfig{
+			{Template: "broken.html", Fallback: "fallback.html", Target: "foo.proto", Output: "foo.html"},
+		},
+	}
+	response, err := Generate(request, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Error != nil {
+		t.Fatalf("unexpected error: %s", response.GetError())
+	}
+	got := response.File[0].GetContent()
+	for _, expected := range []string{"Failed to render foo.proto", "index out of range"} {
+		if !strings.Contains(got, expected) {
+			t.Fatalf("expected %q to contain %q", got, expected)
+		}
+	}
+}
+
+func TestGenerateFallbackTemplateFails(t *testing.T) {
+	request := newTestRequest(&descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+	})
+	config := Config{
+		TemplateRoot: testdataRoot(t),
+		Operations: []OperationConfig{
+			{Template: "broken.html", Fallback: "broken.html", Target: "foo.proto", Output: "foo.html"},
+		},
+	}
+	response, err := Generate(request, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Error == nil {
+		t.Fatal("expected an error when the fallback fails")
+	}
+}
+
+func TestGenerateNestedTypeAnchorsMatchLinks(t *testing.T) {
+	file := &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo/foo.proto"),
+		Package: proto.String("foo"),
+		MessageType: []*descriptor.DescriptorProto{
+			{
+				Name: proto.String("Outer"),
+				Field: []*descriptor.FieldDescriptorProto{
+					{
+						Name:     proto.String("deep"),
+						Number:   proto.Int32(1),
+						Label:    descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+						Type:     descriptor.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+						TypeName: proto.String(".foo.Outer.Inner.Deep"),
+					},
+				},
+				NestedType: []*descriptor.DescriptorProto{
+					{
+						Name:       proto.String("Inner"),
+						NestedType: []*descriptor.DescriptorProto{{Name: proto.String("Deep")}},
+					},
+				},
+			},
+		},
+	}
+	config := Config{
+		TemplateRoot: testdataRoot(t),
+		Operations: []OperationConfig{
+			{Template: "nested.html", Target: "foo/foo.proto", Output: "foo/foo.html"},
+		},
+	}
+	response, err := Generate(newTestRequest(file), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Error != nil {
+		t.Fatalf("unexpected error: %s", response.GetError())
+	}
+
+	got := response.File[0].GetContent()
+	for _, expected := range []string{`id="Outer.Inner.Deep"`, `href="foo/foo.html#Outer.Inner.Deep"`} {
+		if !strings.Contains(got, expected) {
+			t.Fatalf("expected %q to contain %q", got, expected)
+		}
+	}
+}
+
+func TestGenerateChecksums(t *testing.T) {
+	request := newTestRequest(&descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+	})
+	config := Config{
+		TemplateRoot: testdataRoot(t),
+		Checksums:    true,
+		Operations: []OperationConfig{
+			{Template: "target.html", Target: "foo.proto", Output: "foo.html"},
+			{Format: "manifest", Output: "manifest.json"},
+		},
+	}
+	response, err := Generate(request, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Error != nil {
+		t.Fatalf("unexpected error: %s", response.GetError())
+	}
+	if len(response.File) != 3 {
+		t.Fatalf("expected 3 files, got %d", len(response.File))
+	}
+
+	checksums := response.File[2]
+	if checksums.GetName() != "checksums.txt" {
+		t.Fatalf("expected checksums.txt to be last, got %s", checksums.GetName())
+	}
+	var expected string
+	for _, f := range response.File[:2] {
+		expected += fmt.Sprintf("%x  %s\n", sha256.Sum256([]byte(f.GetContent())), f.GetName())
+	}
+	if got := checksums.GetContent(); got != expected {
+		t.Fatalf("got %q expected %q", got, expected)
+	}
+}
+
+func TestGenerateFailOnNoOutput(t *testing.T) {
+	request := newTestRequest(&descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+	})
+	config := Config{
+		TemplateRoot: testdataRoot(t),
+		SkipEmpty:    true,
+		Operations: []OperationConfig{
+			{Template: "empty.html", Target: "foo.proto", Output: "foo.html"},
+		},
+	}
+
+	response, err := Generate(request, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Error != nil || len(response.File) != 0 {
+		t.Fatalf("expected no files and no error, got %v %q", response.File, response.GetError())
+	}
+
+	config.FailOnNoOutput = true
+	response, err = Generate(request, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "no files were generated\n"; response.GetError() != expected {
+		t.Fatalf("got error %q expected %q", response.GetError(), expected)
+	}
+}
+
+func TestGenerateWrapTemplate(t *testing.T) {
+	request := newTestRequest(&descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+	})
+	config := Config{
+		TemplateRoot: testdataRoot(t),
+		Operations: []OperationConfig{
+			{Template: "target.html", Wrap: "wrap.json", Target: "foo.proto", Output: "foo.json"},
+		},
+	}
+	response, err := Generate(request, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Error != nil {
+		t.Fatalf("unexpected error: %s", response.GetError())
+	}
+
+	expected := `{"output": "foo.json", "body": "foo.proto\n"}` + "\n"
+	if got := response.File[0].GetContent(); got != expected {
+		t.Fatalf("got %q expected %q", got, expected)
+	}
+}
+
+func TestGenerateCustomDelims(t *testing.T) {
+	request := newTestRequest(&descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+	})
+	config := Config{
+		TemplateRoot: testdataRoot(t),
+		Operations: []OperationConfig{
+			{
+				Template:   "delims.html",
+				Target:     "foo.proto",
+				Output:     "foo.html",
+				LeftDelim:  "[[",
+				RightDelim: "]]",
+			},
+			{
+				Template:   "target.html",
+				Target:     "foo.proto",
+				Output:     "default.html",
+				LeftDelim:  "",
+				RightDelim: "",
+			},
+		},
+	}
+	response, err := Generate(request, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Error != nil {
+		t.Fatalf("unexpected error: %s", response.GetError())
+	}
+
+	// Files are sorted by name, so default.html is first.
+	expected := []string{"foo.proto\n", "foo.proto {{ message }}\n"}
+	if len(response.File) != len(expected) {
+		t.Fatalf("expected %d files, got %d", len(expected), len(response.File))
+	}
+	for i, file := range response.File {
+		if got := file.GetContent(); got != expected[i] {
+			t.Fatalf("got %q expected %q", got, expected[i])
+		}
+	}
+}
+
+func TestGeneratePartials(t *testing.T) {
+	request := newTestRequest(&descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+	})
+	config := Config{
+		TemplateRoot: testdataRoot(t),
+		Partials:     []string{"partials/*.html"},
+		Operations: []OperationConfig{
+			{Template: "with_partial.html", Target: "foo.proto", Output: "foo.html"},
+		},
+	}
+	response, err := Generate(request, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Error != nil {
+		t.Fatalf("unexpected error: %s", response.GetError())
+	}
+
+	expected := "<h1>foo.proto</h1>\n"
+	if got := response.File[0].GetContent(); got != expected {
+		t.Fatalf("got %q expected %q", got, expected)
+	}
+}
+
+func TestGenerateIndexOperation(t *testing.T) {
+	request := newTestRequest(
+		&descriptor.FileDescriptorProto{
+			Name:    proto.String("foo/foo.proto"),
+			Package: proto.String("foo"),
+			MessageType: []*descriptor.DescriptorProto{
+				{Name: proto.String("Bar")},
+			},
+		},
+		&descriptor.FileDescriptorProto{
+			Name:    proto.String("baz/baz.proto"),
+			Package: proto.String("foo"),
+			MessageType: []*descriptor.DescriptorProto{
+				{Name: proto.String("Qux")},
+			},
+		},
+	)
+	request.FileToGenerate = []string{"foo/foo.proto", "baz/baz.proto"}
+	config := Config{
+		TemplateRoot: testdataRoot(t),
+		Operations: []OperationConfig{
+			{Template: "index.html", Mode: "index", Output: "index.html"},
+			{Template: "target_messages.html", Target: "**/*.proto", Output: "{{.Name}}.html"},
+		},
+	}
+	response, err := Generate(request, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Error != nil {
+		t.Fatalf("unexpected error: %s", response.GetError())
+	}
+	if len(response.File) != 3 {
+		t.Fatalf("expected 3 files, got %d", len(response.File))
+	}
+
+	expected := `<a href="foo/foo.html">foo/foo.html</a>
+<a href="baz/baz.html">baz/baz.html</a>
+<a href="foo/foo.html#Bar">Bar</a>
+<a href="baz/baz.html#Qux">Qux</a>
+`
+	index := response.File[2]
+	if index.GetName() != "index.html" {
+		t.Fatalf("expected index.html to be last, got %s", index.GetName())
+	}
+	if got := index.GetContent(); got != expected {
+		t.Fatalf("got %q expected %q", got, expected)
+	}
+}
+
+func TestGenerateFilesAreSortedByName(t *testing.T) {
+	request := newTestRequest(&descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+	})
+	config := Config{TemplateRoot: testdataRoot(t)}
+	names := []string{"d.html", "b.html", "e.html", "a.html", "c.html"}
+	for _, name := range names {
+		config.Operations = append(config.Operations,
+			OperationConfig{Template: "target.html", Target: "foo.proto", Output: name})
+	}
+	config.Operations = append(config.Operations,
+		OperationConfig{Format: "unknown", Output: "z.html"},
+		OperationConfig{Template: "broken.html", Target: "foo.proto", Output: "y.html"})
+
+	response, err := Generate(request, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Errors are reported in the order of the operations.
+	errs := strings.Split(strings.TrimSpace(response.GetError()), "\n")
+	if len(errs) != 2 || !strings.Contains(errs[0], "unknown format") || !strings.Contains(errs[1], "failed to render") {
+		t.Fatalf("unexpected errors %q", response.GetError())
+	}
+
+	config.Operations = config.Operations[:len(names)]
+	response, err = Generate(request, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, file := range response.File {
+		got = append(got, file.GetName())
+	}
+	expected := []string{"a.html", "b.html", "c.html", "d.html", "e.html"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %v expected %v", got, expected)
+	}
+}
+
+func TestGenerateRenderErrorContext(t *testing.T) {
+	request := newTestRequest(&descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+	})
+	config := Config{
+		TemplateRoot: testdataRoot(t),
+		Operations: []OperationConfig{
+			{Template: "broken.html", Target: "foo.proto", Output: "foo.html"},
+		},
+	}
+	response, err := Generate(request, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `failed to render template broken.html at broken.html:1:2 for target "foo.proto" to "foo.html": `
+	if !strings.HasPrefix(response.GetError(), expected) {
+		t.Fatalf("expected error to start with %q, got %q", expected, response.GetError())
+	}
+}
+
+type panicWriter struct{}
+
+func (panicWriter) Write([]byte) (int, error) {
+	var m map[string]int
+	m["boom"]++
+	return 0, nil
+}
+
+func TestExecuteRecoversPanics(t *testing.T) {
+	tmpl := template.Must(template.New("page.html").Parse("text"))
+	err := execute(tmpl, panicWriter{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "template page.html panicked: assignment to entry in nil map") {
+		t.Fatalf("expected a panic error, got %v", err)
+	}
+}
+
+func TestGenerateOperationURLRoot(t *testing.T) {
+	request := newTestRequest(
+		&descriptor.FileDescriptorProto{
+			Name:        proto.String("foo.proto"),
+			Package:     proto.String("foo"),
+			MessageType: []*descriptor.DescriptorProto{{Name: proto.String("Foo")}},
+		},
+		&descriptor.FileDescriptorProto{
+			Name:        proto.String("bar.proto"),
+			Package:     proto.String("bar"),
+			MessageType: []*descriptor.DescriptorProto{{Name: proto.String("Bar")}},
+		},
+	)
+	config := Config{
+		TemplateRoot: testdataRoot(t),
+		URLRoot:      "/docs",
+		Operations: []OperationConfig{
+			{Template: "url_root.html", Target: "foo.proto", Output: "inherited.html"},
+			{Template: "url_root.html", Target: "foo.proto", Output: "overridden.html", URLRoot: "/foo-docs"},
+		},
+	}
+	response, err := Generate(request, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Error != nil {
+		t.Fatal(response.GetError())
+	}
+	expected := map[string]string{
+		"inherited.html":  "/docs/foo.html#Foo /docs/bar.html#Bar",
+		"overridden.html": "/foo-docs/foo.html#Foo /docs/bar.html#Bar",
+	}
+	for _, file := range response.File {
+		if got := file.GetContent(); got != expected[file.GetName()] {
+			t.Errorf("%s: got %q expected %q", file.GetName(), got, expected[file.GetName()])
+		}
+	}
+}
+
+func TestGeneratePageNamesMatchLinks(t *testing.T) {
+	request := newTestRequest(
+		&descriptor.FileDescriptorProto{
+			Name:        proto.String("api/user_service.proto"),
+			Package:     proto.String("api"),
+			MessageType: []*descriptor.DescriptorProto{{Name: proto.String("Bar")}},
+		},
+	)
+	var testCases = []struct {
+		slugPageNames bool
+		expected      string
+	}{
+		{expected: "api/user_service.html"},
+		{slugPageNames: true, expected: "api/user-service.html"},
+	}
+	for _, testCase := range testCases {
+		config := Config{
+			TemplateRoot:  testdataRoot(t),
+			SlugPageNames: testCase.slugPageNames,
+			Operations: []OperationConfig{
+				{Template: "page_names.html", Target: "**/*.proto", Output: "{{.Name}}.html"},
+			},
+		}
+		response, err := Generate(request, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if response.Error != nil {
+			t.Fatal(response.GetError())
+		}
+		if len(response.File) != 1 {
+			t.Fatalf("expected 1 file, got %d", len(response.File))
+		}
+		file := response.File[0]
+		if file.GetName() != testCase.expected {
+			t.Errorf("got name %q expected %q", file.GetName(), testCase.expected)
+		}
+		if expected := file.GetName() + "#Bar"; file.GetContent() != expected {
+			t.Errorf("got link %q expected %q", file.GetContent(), expected)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package tmpl
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/dnephin/proto-gen-html/util"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+const formatGRPCRef = "grpcref"
+
+// grpcPath returns the path used by gRPC for the method of the service, for
+// example "/foo.Things/Watch".
+func (f *tmplFuncs) grpcPath(service *descriptor.ServiceDescriptorProto, method *descriptor.MethodDescriptorProto) string {
+	return methodGRPCPath(f.serviceFile(service), service, method)
+}
+
+func methodGRPCPath(file *descriptor.FileDescriptorProto, service *descriptor.ServiceDescriptorProto, method *descriptor.MethodDescriptorProto) string {
+	fullName := strings.TrimPrefix(util.FullName(file, service.GetName()), ".")
+	return fmt.Sprintf("/%s/%s", fullName, method.GetName())
+}
+
+// serviceFile returns the file which declares the service, or the target file
+// if the service is not found.
+func (f *tmplFuncs) serviceFile(service *descriptor.ServiceDescriptorProto) *descriptor.FileDescriptorProto {
+	for _, file := range f.protoFiles {
+		for _, v := range file.GetService() {
+			if v == service {
+				return file
+			}
+		}
+	}
+	return f.protoFileDescriptor
+}
+
+// genGRPCRef returns the gRPC path of every method of the files being
+// generated, one per line, in the order of services and methods.
+func (g *generator) genGRPCRef() string {
+	funcs := &tmplFuncs{config: g.config}
+	buf := new(bytes.Buffer)
+	for _, file := range g.filesToGenerate() {
+		for _, service := range funcs.services(file) {
+			for _, method := range funcs.methods(service) {
+				fmt.Fprintln(buf, methodGRPCPath(file, service, method))
+			}
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,76 @@
+package tmpl
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestGRPCPath(t *testing.T) {
+	file := newServicesFile()
+	f := &tmplFuncs{protoFiles: []*descriptor.FileDescriptorProto{file}}
+
+	got := f.grpcPath(file.Service[1], file.Service[1].Method[0])
+	if expected := "/foo.Second/Two"; got != expected {
+		t.Fatalf("got %q expected %q", got, expected)
+	}
+}
+
+func TestGenerateGRPCRef(t *testing.T) {
+	file := newServicesFile()
+	file.Package = proto.String("foo.v1")
+	// The request is loaded by the registry, which requires the input and
+	// output types of methods to exist.
+	file.MessageType = []*descriptor.DescriptorProto{{Name: proto.String("Empty")}}
+	for _, service := range file.Service {
+		for _, method := range service.Method {
+			method.InputType = proto.String(".foo.v1.Empty")
+			method.OutputType = proto.String(".foo.v1.Empty")
+		}
+	}
+	config := Config{
+		Operations: []OperationConfig{{Format: "grpcref", Output: "grpc.txt"}},
+	}
+	response, err := Generate(newTestRequest(file), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Error != nil {
+		t.Fatalf("unexpected error: %s", response.GetError())
+	}
+
+	expected := "/foo.v1.First/One\n/foo.v1.Second/Two\n/foo.v1.Second/Three\n"
+	if got := response.File[0].GetContent(); got != expected {
+		t.Fatalf("got %q expected %q", got, expected)
+	}
+}
+
+func TestGenerateGRPCRefCanonicalHideDeprecated(t *testing.T) {
+	file := newServicesFile()
+	file.MessageType = []*descriptor.DescriptorProto{{Name: proto.String("Empty")}}
+	for _, service := range file.Service {
+		for _, method := range service.Method {
+			method.InputType = proto.String(".foo.Empty")
+			method.OutputType = proto.String(".foo.Empty")
+		}
+	}
+	file.Service[0].Options = &descriptor.ServiceOptions{Deprecated: proto.Bool(true)}
+	config := Config{
+		Operations:     []OperationConfig{{Format: "grpcref", Output: "grpc.txt"}},
+		Canonical:      true,
+		HideDeprecated: true,
+	}
+	response, err := Generate(newTestRequest(file), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Error != nil {
+		t.Fatalf("unexpected error: %s", response.GetError())
+	}
+
+	expected := "/foo.Second/Three\n/foo.Second/Two\n"
+	if got := response.File[0].GetContent(); got != expected {
+		t.Fatalf("got %q expected %q", got, expected)
+	}
+}
@@ -0,0 +1,130 @@
+package tmpl
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/dnephin/proto-gen-html/util"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"google.golang.org/genproto/googleapis/api/annotations"
+)
+
+// httpRule is an HTTP binding of a method from a google.api.http annotation.
+type httpRule struct {
+	// Method is the HTTP method, e.g. "GET", or the kind of a custom pattern.
+	Method string
+	// Path is the path template, e.g. "/v1/items/{id}".
+	Path string
+	// Body is the field of the request message which is mapped to the HTTP
+	// request body, or "*" for every field not bound by the path.
+	Body string
+}
+
+// methodHTTPRules returns the HTTP bindings of the method, including any
+// additional bindings. Methods without a google.api.http annotation have no
+// bindings.
+func methodHTTPRules(method *descriptor.MethodDescriptorProto) []httpRule {
+	if method.GetOptions() == nil {
+		return nil
+	}
+	ext, err := proto.GetExtension(method.GetOptions(), annotations.E_Http)
+	if err != nil {
+		return nil
+	}
+	rule, ok := ext.(*annotations.HttpRule)
+	if !ok || rule == nil {
+		return nil
+	}
+
+	var rules []httpRule
+	for _, r := range append([]*annotations.HttpRule{rule}, rule.GetAdditionalBindings()...) {
+		entry := httpRule{Body: r.GetBody()}
+		switch {
+		case r.GetGet() != "":
+			entry.Method, entry.Path = "GET", r.GetGet()
+		case r.GetPut() != "":
+			entry.Method, entry.Path = "PUT", r.GetPut()
+		case r.GetPost() != "":
+			entry.Method, entry.Path = "POST", r.GetPost()
+		case r.GetDelete() != "":
+			entry.Method, entry.Path = "DELETE", r.GetDelete()
+		case r.GetPatch() != "":
+			entry.Method, entry.Path = "PATCH", r.GetPatch()
+		case r.GetCustom() != nil:
+			entry.Method, entry.Path = r.GetCustom().GetKind(), r.GetCustom().GetPath()
+		default:
+			continue
+		}
+		rules = append(rules, entry)
+	}
+	return rules
+}
+
+// pathParam is a field of the request message bound to a path variable.
+type pathParam struct {
+	// Name is the field path of the variable, e.g. "id" or "parent.id".
+	Name string
+	// Type is the display type of the field, see displayFieldType, or empty if
+	// the field could not be found in the request message.
+	Type string
+}
+
+// pathVariablePattern matches the variables of a path template, e.g. "{id}" or
+// "{name=shelves/*}".
+var pathVariablePattern = regexp.MustCompile(`\{([^}=]+)(=[^}]*)?\}`)
+
+// pathParams returns the fields of the request message which are bound to the
+// variables in the HTTP paths of the method. Each variable is listed once, in
+// the order they appear.
+func (f *tmplFuncs) pathParams(method *descriptor.MethodDescriptorProto) []pathParam {
+	var (
+		params []pathParam
+		seen   = make(map[string]bool)
+	)
+	for _, rule := range methodHTTPRules(method) {
+		for _, match := range pathVariablePattern.FindAllStringSubmatch(rule.Path, -1) {
+			name := strings.TrimSpace(match[1])
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			param := pathParam{Name: name}
+			if field := f.resolveFieldPath(method.GetInputType(), name); field != nil {
+				param.Type = f.displayFieldType(field)
+			}
+			params = append(params, param)
+		}
+	}
+	return params
+}
+
+// resolveFieldPath returns the field with the dotted fieldPath in the message
+// with the fully-qualified symbolPath, or nil if it can not be found.
+func (f *tmplFuncs) resolveFieldPath(symbolPath, fieldPath string) *descriptor.FieldDescriptorProto {
+	resolver := util.NewResolver(f.protoFiles)
+	var field *descriptor.FieldDescriptorProto
+	for _, name := range strings.Split(fieldPath, ".") {
+		if symbolPath == "" {
+			return nil
+		}
+		node, _ := resolver.Resolve(symbolPath, nil)
+		msg, ok := node.(*descriptor.DescriptorProto)
+		if !ok {
+			return nil
+		}
+		field = nil
+		for _, v := range msg.GetField() {
+			if v.GetName() == name {
+				field = v
+				break
+			}
+		}
+		if field == nil {
+			return nil
+		}
+		symbolPath = field.GetTypeName()
+	}
+	return field
+}
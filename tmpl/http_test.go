@@ -0,0 +1,109 @@
+package tmpl
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"google.golang.org/genproto/googleapis/api/annotations"
+)
+
+// newHTTPMethod returns a method of the foo.Items service annotated with the
+// http rule.
+func newHTTPMethod(t *testing.T, rule *annotations.HttpRule) *descriptor.MethodDescriptorProto {
+	options := &descriptor.MethodOptions{}
+	if err := proto.SetExtension(options, annotations.E_Http, rule); err != nil {
+		t.Fatal(err)
+	}
+	return &descriptor.MethodDescriptorProto{
+		Name:       proto.String("GetItem"),
+		InputType:  proto.String(".foo.GetItemRequest"),
+		OutputType: proto.String(".foo.Item"),
+		Options:    options,
+	}
+}
+
+func newHTTPFile(method *descriptor.MethodDescriptorProto) *descriptor.FileDescriptorProto {
+	return &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+		MessageType: []*descriptor.DescriptorProto{
+			{
+				Name: proto.String("GetItemRequest"),
+				Field: []*descriptor.FieldDescriptorProto{
+					{
+						Name:   proto.String("id"),
+						Number: proto.Int32(1),
+						Type:   descriptor.FieldDescriptorProto_TYPE_STRING.Enum(),
+					},
+				},
+			},
+			{Name: proto.String("Item")},
+		},
+		Service: []*descriptor.ServiceDescriptorProto{
+			{
+				Name:   proto.String("Items"),
+				Method: []*descriptor.MethodDescriptorProto{method},
+			},
+		},
+	}
+}
+
+func TestPathParams(t *testing.T) {
+	method := newHTTPMethod(t, &annotations.HttpRule{
+		Pattern: &annotations.HttpRule_Get{Get: "/v1/items/{id}"},
+	})
+	f := newTestFuncs(Config{}, newHTTPFile(method))
+
+	got := f.pathParams(method)
+	expected := []pathParam{{Name: "id", Type: "string"}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %+v expected %+v", got, expected)
+	}
+}
+
+func TestPathParamsScalarDisplayNames(t *testing.T) {
+	method := newHTTPMethod(t, &annotations.HttpRule{
+		Pattern: &annotations.HttpRule_Get{Get: "/v1/items/{id}"},
+	})
+	f := newTestFuncs(Config{ScalarDisplayNames: map[string]string{"string": "text"}}, newHTTPFile(method))
+
+	got := f.pathParams(method)
+	expected := []pathParam{{Name: "id", Type: "text"}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %+v expected %+v", got, expected)
+	}
+}
+
+func TestMethodHTTPRules(t *testing.T) {
+	method := newHTTPMethod(t, &annotations.HttpRule{
+		Pattern: &annotations.HttpRule_Post{Post: "/v1/items"},
+		Body:    "*",
+		AdditionalBindings: []*annotations.HttpRule{
+			{Pattern: &annotations.HttpRule_Put{Put: "/v1/items/{id}"}, Body: "item"},
+			{Pattern: &annotations.HttpRule_Custom{Custom: &annotations.CustomHttpPattern{Kind: "HEAD", Path: "/v1/items"}}},
+		},
+	})
+
+	got := methodHTTPRules(method)
+	expected := []httpRule{
+		{Method: "POST", Path: "/v1/items", Body: "*"},
+		{Method: "PUT", Path: "/v1/items/{id}", Body: "item"},
+		{Method: "HEAD", Path: "/v1/items"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %+v expected %+v", got, expected)
+	}
+}
+
+func TestMethodHTTPRulesWithoutAnnotation(t *testing.T) {
+	for _, method := range []*descriptor.MethodDescriptorProto{
+		{Name: proto.String("NoOptions")},
+		{Name: proto.String("OtherOptions"), Options: &descriptor.MethodOptions{Deprecated: proto.Bool(true)}},
+	} {
+		if got := methodHTTPRules(method); len(got) != 0 {
+			t.Fatalf("%s: expected no rules, got %+v", method.GetName(), got)
+		}
+	}
+}
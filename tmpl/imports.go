@@ -0,0 +1,65 @@
+package tmpl
+
+import (
+	"strings"
+
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// importEntry is a file imported by another file.
+type importEntry struct {
+	Name string
+	// Direct is true if the file is imported by the file itself, and false if
+	// it is only imported by one of its imports.
+	Direct bool
+}
+
+// importClosure returns all the files imported by file, directly or
+// transitively. Each file is listed once, direct imports first in the order
+// they are declared, followed by transitive imports in the order they are
+// found.
+func (f *tmplFuncs) importClosure(file *descriptor.FileDescriptorProto) []importEntry {
+	files := make(map[string]*descriptor.FileDescriptorProto, len(f.protoFiles))
+	for _, v := range f.protoFiles {
+		files[v.GetName()] = v
+	}
+
+	seen := map[string]bool{file.GetName(): true}
+	var all []importEntry
+	queue := append([]string{}, file.GetDependency()...)
+	for i := 0; i < len(queue); i++ {
+		name := queue[i]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		all = append(all, importEntry{Name: name, Direct: i < len(file.GetDependency())})
+
+		if dep, ok := files[name]; ok {
+			queue = append(queue, dep.GetDependency()...)
+		}
+	}
+	return all
+}
+
+// goImportPath returns the Go import path from the go_package option of the
+// file, without the package name if the option has the "path;name" form.
+func goImportPath(file *descriptor.FileDescriptorProto) string {
+	importPath, _ := splitGoPackage(file)
+	return importPath
+}
+
+// goPackageAlias returns the Go package name from a go_package option of the
+// form "path;name", or an empty string if the option does not set a name.
+func goPackageAlias(file *descriptor.FileDescriptorProto) string {
+	_, alias := splitGoPackage(file)
+	return alias
+}
+
+func splitGoPackage(file *descriptor.FileDescriptorProto) (string, string) {
+	goPackage := file.GetOptions().GetGoPackage()
+	if i := strings.Index(goPackage, ";"); i >= 0 {
+		return goPackage[:i], goPackage[i+1:]
+	}
+	return goPackage, ""
+}
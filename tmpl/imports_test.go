@@ -0,0 +1,59 @@
+package tmpl
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestImportClosure(t *testing.T) {
+	a := &descriptor.FileDescriptorProto{
+		Name:       proto.String("a.proto"),
+		Dependency: []string{"b.proto"},
+	}
+	b := &descriptor.FileDescriptorProto{
+		Name:       proto.String("b.proto"),
+		Dependency: []string{"c.proto"},
+	}
+	c := &descriptor.FileDescriptorProto{
+		Name: proto.String("c.proto"),
+		// protoc rejects import cycles, but they should not loop forever.
+		Dependency: []string{"a.proto"},
+	}
+	f := &tmplFuncs{protoFiles: []*descriptor.FileDescriptorProto{c, b, a}}
+
+	got := f.importClosure(a)
+	expected := []importEntry{
+		{Name: "b.proto", Direct: true},
+		{Name: "c.proto", Direct: false},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %+v expected %+v", got, expected)
+	}
+}
+
+func TestGoImportPath(t *testing.T) {
+	var testCases = []struct {
+		goPackage    string
+		expectedPath string
+		expectedName string
+	}{
+		{goPackage: "example.com/foo;foopb", expectedPath: "example.com/foo", expectedName: "foopb"},
+		{goPackage: "example.com/foo", expectedPath: "example.com/foo"},
+		{goPackage: ""},
+	}
+	for _, testCase := range testCases {
+		file := &descriptor.FileDescriptorProto{
+			Name:    proto.String("foo.proto"),
+			Options: &descriptor.FileOptions{GoPackage: proto.String(testCase.goPackage)},
+		}
+		if got := goImportPath(file); got != testCase.expectedPath {
+			t.Errorf("%q: got path %q expected %q", testCase.goPackage, got, testCase.expectedPath)
+		}
+		if got := goPackageAlias(file); got != testCase.expectedName {
+			t.Errorf("%q: got alias %q expected %q", testCase.goPackage, got, testCase.expectedName)
+		}
+	}
+}
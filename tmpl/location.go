@@ -0,0 +1,55 @@
+package tmpl
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// fileLocations maps the descriptor nodes of a file to their source code info
+// location.
+type fileLocations map[interface{}]*descriptor.SourceCodeInfo_Location
+
+// locationCache is the index of the locations of each proto file. It is built
+// once per file, and shared by the operations of a generator, which may use it
+// concurrently.
+type locationCache struct {
+	mu    sync.Mutex
+	files map[string]fileLocations
+}
+
+func newLocationCache() *locationCache {
+	return &locationCache{files: make(map[string]fileLocations)}
+}
+
+// file returns the locations of the nodes of the file, building the index of
+// the file if it is not yet cached.
+func (c *locationCache) file(file *descriptor.FileDescriptorProto) fileLocations {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if locations, ok := c.files[file.GetName()]; ok {
+		return locations
+	}
+
+	locations := make(fileLocations)
+	for _, loc := range file.GetSourceCodeInfo().GetLocation() {
+		node := walkPath(loc.Path, file)
+		if !isNodeKey(node) {
+			continue
+		}
+		// The first location of a node is used, like the order of the
+		// locations in the file.
+		if _, ok := locations[node]; !ok {
+			locations[node] = loc
+		}
+	}
+	c.files[file.GetName()] = locations
+	return locations
+}
+
+// isNodeKey returns true if x can be a key of fileLocations. Only pointers are
+// used, because values such as slices can not be compared.
+func isNodeKey(x interface{}) bool {
+	return x != nil && reflect.ValueOf(x).Kind() == reflect.Ptr
+}
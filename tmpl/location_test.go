@@ -0,0 +1,91 @@
+package tmpl
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// newLargeFile returns a file with messages messages of fields fields each,
+// with a comment for every message and field.
+func newLargeFile(messages, fields int) *descriptor.FileDescriptorProto {
+	file := &descriptor.FileDescriptorProto{
+		Name:           proto.String("large.proto"),
+		Package:        proto.String("large"),
+		SourceCodeInfo: &descriptor.SourceCodeInfo{},
+	}
+	for i := 0; i < messages; i++ {
+		msg := &descriptor.DescriptorProto{Name: proto.String(fmt.Sprintf("Message%d", i))}
+		file.SourceCodeInfo.Location = append(file.SourceCodeInfo.Location, &descriptor.SourceCodeInfo_Location{
+			Path:            []int32{4, int32(i)},
+			LeadingComments: proto.String(msg.GetName()),
+		})
+		for j := 0; j < fields; j++ {
+			field := &descriptor.FieldDescriptorProto{
+				Name:   proto.String(fmt.Sprintf("field%d", j)),
+				Number: proto.Int32(int32(j + 1)),
+			}
+			msg.Field = append(msg.Field, field)
+			file.SourceCodeInfo.Location = append(file.SourceCodeInfo.Location, &descriptor.SourceCodeInfo_Location{
+				Path:            []int32{4, int32(i), 2, int32(j)},
+				LeadingComments: proto.String(msg.GetName() + "." + field.GetName()),
+			})
+		}
+		file.MessageType = append(file.MessageType, msg)
+	}
+	return file
+}
+
+func TestLocation(t *testing.T) {
+	file := newLargeFile(3, 2)
+	f := &tmplFuncs{protoFileDescriptor: file}
+
+	msg := file.MessageType[2]
+	if got := f.location(msg).GetLeadingComments(); got != "Message2" {
+		t.Fatalf("got %q expected Message2", got)
+	}
+	if got := f.location(msg.Field[1]).GetLeadingComments(); got != "Message2.field1" {
+		t.Fatalf("got %q expected Message2.field1", got)
+	}
+	if got := f.location(&descriptor.DescriptorProto{}); got != nil {
+		t.Fatalf("expected no location for an unknown node, got %v", got)
+	}
+}
+
+func TestLocationCacheIsSharedByOperations(t *testing.T) {
+	file := newLargeFile(1, 1)
+	cache := newLocationCache()
+	first := &tmplFuncs{protoFileDescriptor: file, locations: cache}
+	second := &tmplFuncs{protoFileDescriptor: file, locations: cache}
+
+	if first.location(file.MessageType[0]) == nil || second.location(file.MessageType[0]) == nil {
+		t.Fatal("expected a location for the message")
+	}
+	if len(cache.files) != 1 {
+		t.Fatalf("expected the file to be indexed once, got %d", len(cache.files))
+	}
+}
+
+func BenchmarkLocation(b *testing.B) {
+	file := newLargeFile(500, 20)
+	var nodes []interface{}
+	for _, msg := range file.MessageType {
+		nodes = append(nodes, msg)
+		for _, field := range msg.Field {
+			nodes = append(nodes, field)
+		}
+	}
+	cache := newLocationCache()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f := &tmplFuncs{protoFileDescriptor: file, locations: cache}
+		for _, node := range nodes {
+			if f.location(node) == nil {
+				b.Fatal("expected a location")
+			}
+		}
+	}
+}
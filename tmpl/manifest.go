@@ -0,0 +1,109 @@
+package tmpl
+
+import (
+	"encoding/json"
+
+	"github.com/dnephin/proto-gen-html/util"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+const formatManifest = "manifest"
+
+// Manifest is the machine-readable listing of the documented API written by
+// operations with the "manifest" Format. Type names are fully-qualified, and
+// URLs point to the html page generated for the file which defines the type.
+type Manifest struct {
+	Services []ManifestService `json:"services"`
+	Messages []ManifestType    `json:"messages"`
+	Enums    []ManifestType    `json:"enums"`
+}
+
+// ManifestService is a service in the Manifest.
+type ManifestService struct {
+	Name    string           `json:"name"`
+	File    string           `json:"file"`
+	URL     string           `json:"url"`
+	Methods []ManifestMethod `json:"methods"`
+}
+
+// ManifestMethod is a method of a ManifestService.
+type ManifestMethod struct {
+	Name            string `json:"name"`
+	URL             string `json:"url"`
+	InputType       string `json:"inputType"`
+	OutputType      string `json:"outputType"`
+	ClientStreaming bool   `json:"clientStreaming"`
+	ServerStreaming bool   `json:"serverStreaming"`
+}
+
+// ManifestType is a message or enum in the Manifest.
+type ManifestType struct {
+	Name string `json:"name"`
+	File string `json:"file"`
+	URL  string `json:"url"`
+}
+
+// genManifest returns the JSON encoded manifest for the files being generated.
+// Services, methods, messages, and enums are listed in the same order and with
+// the same declarations excluded as by the template functions, e.g. services
+// and allMessages.
+func (g *generator) genManifest(opConfig OperationConfig) (string, error) {
+	funcs := &tmplFuncs{
+		// Links in the manifest point at the html pages of each file.
+		outputFile: trimExt(opConfig.Output) + ".html",
+		urlRoot:    g.urlRoot(opConfig),
+		protoFiles: g.request.GetProtoFile(),
+		files:      g.filesToGenerate(),
+		locations:  g.locations,
+		config:     g.config,
+	}
+	manifest := Manifest{
+		Services: []ManifestService{},
+		Messages: []ManifestType{},
+		Enums:    []ManifestType{},
+	}
+
+	for _, file := range g.filesToGenerate() {
+		for _, service := range funcs.services(file) {
+			fullName := util.FullName(file, service.GetName())
+			s := ManifestService{
+				Name:    fullName,
+				File:    file.GetName(),
+				URL:     funcs.typeURL(fullName),
+				Methods: []ManifestMethod{},
+			}
+			for _, method := range funcs.methods(service) {
+				s.Methods = append(s.Methods, ManifestMethod{
+					Name:            method.GetName(),
+					URL:             funcs.methodURL(service, method),
+					InputType:       method.GetInputType(),
+					OutputType:      method.GetOutputType(),
+					ClientStreaming: method.GetClientStreaming(),
+					ServerStreaming: method.GetServerStreaming(),
+				})
+			}
+			manifest.Services = append(manifest.Services, s)
+		}
+		for _, msg := range funcs.allMessages(file) {
+			manifest.Messages = append(manifest.Messages, funcs.manifestType(file, msg))
+		}
+		for _, enum := range funcs.allEnums(file) {
+			manifest.Enums = append(manifest.Enums, funcs.manifestType(file, enum))
+		}
+	}
+
+	out, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(out) + "\n", nil
+}
+
+func (f *tmplFuncs) manifestType(file *descriptor.FileDescriptorProto, node util.ASTNamedNode) ManifestType {
+	fullName := util.FullName(file, node.GetName())
+	return ManifestType{
+		Name: fullName,
+		File: file.GetName(),
+		URL:  f.typeURL(fullName),
+	}
+}
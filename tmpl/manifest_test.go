@@ -0,0 +1,146 @@
+package tmpl
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestGenerateManifest(t *testing.T) {
+	file := &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo/foo.proto"),
+		Package: proto.String("foo"),
+		MessageType: []*descriptor.DescriptorProto{
+			{Name: proto.String("Request")},
+			{Name: proto.String("Response")},
+		},
+		EnumType: []*descriptor.EnumDescriptorProto{
+			{Name: proto.String("Kind")},
+		},
+		Service: []*descriptor.ServiceDescriptorProto{
+			{
+				Name: proto.String("Things"),
+				Method: []*descriptor.MethodDescriptorProto{
+					{
+						Name:            proto.String("Watch"),
+						InputType:       proto.String(".foo.Request"),
+						OutputType:      proto.String(".foo.Response"),
+						ServerStreaming: proto.Bool(true),
+					},
+				},
+			},
+		},
+	}
+	config := Config{
+		Operations: []OperationConfig{
+			{Format: "manifest", Output: "manifest.json"},
+		},
+	}
+	response, err := Generate(newTestRequest(file), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Error != nil {
+		t.Fatalf("unexpected error: %s", response.GetError())
+	}
+
+	var got Manifest
+	if err := json.Unmarshal([]byte(response.File[0].GetContent()), &got); err != nil {
+		t.Fatal(err)
+	}
+	expected := Manifest{
+		Services: []ManifestService{
+			{
+				Name: ".foo.Things",
+				File: "foo/foo.proto",
+				URL:  "foo/foo.html#Things",
+				Methods: []ManifestMethod{
+					{
+						Name:            "Watch",
+						URL:             "foo/foo.html#Things.Watch",
+						InputType:       ".foo.Request",
+						OutputType:      ".foo.Response",
+						ServerStreaming: true,
+					},
+				},
+			},
+		},
+		Messages: []ManifestType{
+			{Name: ".foo.Request", File: "foo/foo.proto", URL: "foo/foo.html#Request"},
+			{Name: ".foo.Response", File: "foo/foo.proto", URL: "foo/foo.html#Response"},
+		},
+		Enums: []ManifestType{
+			{Name: ".foo.Kind", File: "foo/foo.proto", URL: "foo/foo.html#Kind"},
+		},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %+v expected %+v", got, expected)
+	}
+}
+
+func TestGenerateManifestCanonicalHideDeprecated(t *testing.T) {
+	deprecated := &descriptor.MessageOptions{Deprecated: proto.Bool(true)}
+	file := &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+		MessageType: []*descriptor.DescriptorProto{
+			{Name: proto.String("Zeta")},
+			{Name: proto.String("Old"), Options: deprecated},
+			{Name: proto.String("Alpha")},
+		},
+		Service: []*descriptor.ServiceDescriptorProto{
+			{
+				Name: proto.String("Things"),
+				Method: []*descriptor.MethodDescriptorProto{
+					{Name: proto.String("Watch"), InputType: proto.String(".foo.Alpha"), OutputType: proto.String(".foo.Zeta")},
+					{Name: proto.String("Get"), InputType: proto.String(".foo.Alpha"), OutputType: proto.String(".foo.Zeta")},
+				},
+			},
+			{
+				Name:    proto.String("Legacy"),
+				Options: &descriptor.ServiceOptions{Deprecated: proto.Bool(true)},
+			},
+		},
+	}
+	config := Config{
+		Canonical:      true,
+		HideDeprecated: true,
+		Operations: []OperationConfig{
+			{Format: "manifest", Output: "manifest.json"},
+		},
+	}
+	response, err := Generate(newTestRequest(file), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Error != nil {
+		t.Fatalf("unexpected error: %s", response.GetError())
+	}
+
+	var got Manifest
+	if err := json.Unmarshal([]byte(response.File[0].GetContent()), &got); err != nil {
+		t.Fatal(err)
+	}
+	var messages, services, methods []string
+	for _, msg := range got.Messages {
+		messages = append(messages, msg.Name)
+	}
+	for _, service := range got.Services {
+		services = append(services, service.Name)
+		for _, method := range service.Methods {
+			methods = append(methods, method.Name)
+		}
+	}
+	if expected := []string{".foo.Alpha", ".foo.Zeta"}; !reflect.DeepEqual(messages, expected) {
+		t.Errorf("got messages %v expected %v", messages, expected)
+	}
+	if expected := []string{".foo.Things"}; !reflect.DeepEqual(services, expected) {
+		t.Errorf("got services %v expected %v", services, expected)
+	}
+	if expected := []string{"Get", "Watch"}; !reflect.DeepEqual(methods, expected) {
+		t.Errorf("got methods %v expected %v", methods, expected)
+	}
+}
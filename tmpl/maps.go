@@ -0,0 +1,91 @@
+package tmpl
+
+import (
+	"fmt"
+	"html/template"
+
+	"github.com/dnephin/proto-gen-html/util"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// mapEntryFields are the key and value fields of the synthetic entry message
+// of a map field.
+type mapEntryFields struct {
+	Key   *descriptor.FieldDescriptorProto
+	Value *descriptor.FieldDescriptorProto
+}
+
+// mapEntry returns the key and value fields of a map field, or nil if the
+// field is not a map.
+func (f *tmplFuncs) mapEntry(field *descriptor.FieldDescriptorProto) *mapEntryFields {
+	if field.GetTypeName() == "" || field.GetLabel() != descriptor.FieldDescriptorProto_LABEL_REPEATED {
+		return nil
+	}
+	node, _ := util.NewResolver(f.protoFiles).Resolve(field.GetTypeName(), nil)
+	msg, ok := node.(*descriptor.DescriptorProto)
+	if !ok || !msg.GetOptions().GetMapEntry() {
+		return nil
+	}
+
+	entry := &mapEntryFields{}
+	for _, v := range msg.GetField() {
+		switch v.GetNumber() {
+		case 1:
+			entry.Key = v
+		case 2:
+			entry.Value = v
+		}
+	}
+	if entry.Key == nil || entry.Value == nil {
+		return nil
+	}
+	return entry
+}
+
+// mapType returns the type of a map field as "map<key, value>", or an empty
+// string if the field is not a map. The key and value types are displayed like
+// fieldType, fieldTypeLink renders the same type with links.
+func (f *tmplFuncs) mapType(field *descriptor.FieldDescriptorProto) string {
+	entry := f.mapEntry(field)
+	if entry == nil {
+		return ""
+	}
+	return fmt.Sprintf("map<%s, %s>", f.displayFieldType(entry.Key), f.displayFieldType(entry.Value))
+}
+
+// fieldTypeFull returns the type of the field prefixed by its label as it is
+// written in the proto file, e.g. "repeated string" or "optional int32" in
+// proto2 files. Map fields are "map<key, value>" without a label, and fields
+// of proto3 files are only prefixed when they are repeated or declared
+// optional.
+func (f *tmplFuncs) fieldTypeFull(field *descriptor.FieldDescriptorProto) string {
+	if typeName := f.mapType(field); typeName != "" {
+		return typeName
+	}
+	return fieldLabel(f.fieldFile(field), field) + f.displayFieldType(field)
+}
+
+// fieldTypeLink returns the type of the field as HTML, with message and enum
+// types linked to their documentation. Map fields are rendered as
+// map<key, value> with the value type linked.
+func (f *tmplFuncs) fieldTypeLink(field *descriptor.FieldDescriptorProto) template.HTML {
+	if entry := f.mapEntry(field); entry != nil {
+		return template.HTML(fmt.Sprintf("map&lt;%s, %s&gt;",
+			f.typeLink(entry.Key), f.typeLink(entry.Value)))
+	}
+	return f.typeLink(field)
+}
+
+// typeLink returns the type of a single value of the field as HTML, linked to
+// the documentation of the type if it has any.
+func (f *tmplFuncs) typeLink(field *descriptor.FieldDescriptorProto) template.HTML {
+	name := template.HTMLEscapeString(f.displayFieldType(field))
+	if field.GetTypeName() == "" {
+		return template.HTML(name)
+	}
+	url := f.typeURL(field.GetTypeName())
+	if url == "" {
+		return template.HTML(name)
+	}
+	return template.HTML(fmt.Sprintf(`<a href="%s">%s</a>`, template.HTMLEscapeString(url), name))
+}
@@ -0,0 +1,169 @@
+package tmpl
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// newMapFile returns a file with the message:
+//
+//	message Bar {
+//	    map<string, Foo> foos = 1;
+//	    Foo foo = 2;
+//	}
+func newMapFile() *descriptor.FileDescriptorProto {
+	return &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+		MessageType: []*descriptor.DescriptorProto{
+			{Name: proto.String("Foo")},
+			{
+				Name: proto.String("Bar"),
+				Field: []*descriptor.FieldDescriptorProto{
+					{
+						Name:     proto.String("foos"),
+						Number:   proto.Int32(1),
+						Label:    descriptor.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+						Type:     descriptor.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+						TypeName: proto.String(".foo.Bar.FoosEntry"),
+					},
+					{
+						Name:     proto.String("foo"),
+						Number:   proto.Int32(2),
+						Label:    descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+						Type:     descriptor.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+						TypeName: proto.String(".foo.Foo"),
+					},
+				},
+				NestedType: []*descriptor.DescriptorProto{
+					{
+						Name: proto.String("FoosEntry"),
+						Field: []*descriptor.FieldDescriptorProto{
+							{
+								Name:   proto.String("key"),
+								Number: proto.Int32(1),
+								Label:  descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+								Type:   descriptor.FieldDescriptorProto_TYPE_STRING.Enum(),
+							},
+							{
+								Name:     proto.String("value"),
+								Number:   proto.Int32(2),
+								Label:    descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+								Type:     descriptor.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+								TypeName: proto.String(".foo.Foo"),
+							},
+						},
+						Options: &descriptor.MessageOptions{MapEntry: proto.Bool(true)},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestFieldTypeLinkMapValue(t *testing.T) {
+	f := newTestFuncs(Config{}, newMapFile())
+	field := f.protoFiles[0].MessageType[1].Field[0]
+
+	got := string(f.fieldTypeLink(field))
+	expected := `map&lt;string, <a href="foo.html#Foo">Foo</a>&gt;`
+	if got != expected {
+		t.Fatalf("got %q expected %q", got, expected)
+	}
+}
+
+func TestFieldTypeLinkMessage(t *testing.T) {
+	f := newTestFuncs(Config{}, newMapFile())
+	field := f.protoFiles[0].MessageType[1].Field[1]
+
+	if entry := f.mapEntry(field); entry != nil {
+		t.Fatalf("expected a message field not to be a map, got %+v", entry)
+	}
+	got := string(f.fieldTypeLink(field))
+	expected := `<a href="foo.html#Foo">Foo</a>`
+	if got != expected {
+		t.Fatalf("got %q expected %q", got, expected)
+	}
+}
+
+func TestMapType(t *testing.T) {
+	f := newTestFuncs(Config{}, newMapFile())
+	fields := f.protoFiles[0].MessageType[1].Field
+
+	if got, expected := f.mapType(fields[0]), "map<string, Foo>"; got != expected {
+		t.Fatalf("got %q expected %q", got, expected)
+	}
+	if got := f.mapType(fields[1]); got != "" {
+		t.Fatalf("expected no map type for a message field, got %q", got)
+	}
+}
+
+func TestAllMessagesHideMapEntries(t *testing.T) {
+	f := newTestFuncs(Config{}, newMapFile())
+	names := func() []string {
+		var names []string
+		for _, msg := range f.allMessages(f.protoFileDescriptor) {
+			names = append(names, msg.GetName())
+		}
+		return names
+	}
+
+	if got, expected := names(), []string{"Foo", "Bar", "Bar.FoosEntry"}; !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %v expected %v", got, expected)
+	}
+	f.config.HideMapEntries = true
+	if got, expected := names(), []string{"Foo", "Bar"}; !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %v expected %v", got, expected)
+	}
+}
+
+func TestFieldTypeFull(t *testing.T) {
+	f := newTestFuncs(Config{}, newMapFile())
+	file := f.protoFileDescriptor
+	bar := file.MessageType[1]
+	bar.Field = append(bar.Field,
+		&descriptor.FieldDescriptorProto{
+			Name:   proto.String("names"),
+			Number: proto.Int32(3),
+			Label:  descriptor.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+			Type:   descriptor.FieldDescriptorProto_TYPE_STRING.Enum(),
+		},
+		&descriptor.FieldDescriptorProto{
+			Name:     proto.String("all"),
+			Number:   proto.Int32(4),
+			Label:    descriptor.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+			Type:     descriptor.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+			TypeName: proto.String(".foo.Foo"),
+		},
+		&descriptor.FieldDescriptorProto{
+			Name:   proto.String("count"),
+			Number: proto.Int32(5),
+			Label:  descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:   descriptor.FieldDescriptorProto_TYPE_INT32.Enum(),
+		},
+	)
+
+	var tests = []struct {
+		syntax   string
+		field    int
+		expected string
+	}{
+		{"proto3", 0, "map<string, Foo>"},
+		{"proto3", 1, "Foo"},
+		{"proto3", 2, "repeated string"},
+		{"proto3", 3, "repeated Foo"},
+		{"proto3", 4, "int32"},
+		{"proto2", 0, "map<string, Foo>"},
+		{"proto2", 2, "repeated string"},
+		{"proto2", 4, "optional int32"},
+	}
+	for _, test := range tests {
+		file.Syntax = proto.String(test.syntax)
+		if got := f.fieldTypeFull(bar.Field[test.field]); got != test.expected {
+			t.Errorf("%s %s: got %q expected %q", test.syntax, bar.Field[test.field].GetName(), got, test.expected)
+		}
+	}
+}
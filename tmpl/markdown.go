@@ -0,0 +1,261 @@
+package tmpl
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dnephin/proto-gen-html/util"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/pkg/errors"
+	"gopkg.in/russross/blackfriday.v2"
+)
+
+// markdown renders the markdown source as HTML. When Config.AutolinkTypes is
+// set, type names mentioned in the source are linked to their documentation.
+// When Config.CommentHeadingAnchors is set, headings are given an id which is
+// prefixed by the optional name of the symbol which owns the comment.
+func (f *tmplFuncs) markdown(source string, symbol ...string) template.HTML {
+	if f.config.AutolinkTypes {
+		source = f.autolinkTypes(source)
+	}
+	if f.config.Markdown.TaskLists {
+		source = taskLists(source)
+	}
+	out := blackfriday.Run([]byte(source))
+	if policy := sanitizePolicy(f.config.Markdown.Sanitize); policy != nil {
+		out = policy.SanitizeBytes(out)
+	}
+	if f.config.CommentHeadingAnchors {
+		out = headingAnchors(out, strings.Join(symbol, "."))
+	}
+	return template.HTML(out)
+}
+
+// headingPattern matches the h2 to h6 elements, without attributes, rendered
+// by blackfriday.
+var headingPattern = regexp.MustCompile(`<h([2-6])>(.*?)</h[2-6]>`)
+
+// tagPattern matches an html tag.
+var tagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// headingAnchors adds an id to each h2 to h6 heading of the html, from the
+// slug of the heading text. The ids are prefixed with the slug of the symbol
+// so that the headings of different symbols on a page do not collide.
+// Headings with the same slug are disambiguated like slugAnchor, with a "-1",
+// "-2", ... suffix, skipping ids which are already used by other headings.
+func headingAnchors(html []byte, symbol string) []byte {
+	prefix := ""
+	if s := slug(symbol); s != "" {
+		prefix = s + "-"
+	}
+	seen := make(map[string]bool)
+	return headingPattern.ReplaceAllFunc(html, func(heading []byte) []byte {
+		match := headingPattern.FindSubmatch(heading)
+		base := prefix + slug(string(tagPattern.ReplaceAll(match[2], nil)))
+		id := base
+		for n := 1; seen[id]; n++ {
+			id = fmt.Sprintf("%s-%d", base, n)
+		}
+		seen[id] = true
+		return []byte(fmt.Sprintf(`<h%s id="%s">%s</h%s>`, match[1], id, match[2], match[1]))
+	})
+}
+
+// Policies of MarkdownOptions.Sanitize.
+const (
+	sanitizeStrict = "strict"
+	sanitizeUGC    = "ugc"
+	sanitizeNone   = "none"
+)
+
+var (
+	strictPolicy = bluemonday.StrictPolicy()
+	ugcPolicy    = newUGCPolicy()
+)
+
+// newUGCPolicy returns a policy which allows the formatting that markdown
+// produces. Unlike the bluemonday UGC policy, links do not require
+// rel="nofollow" because they are expected to link to other documentation,
+// and the checkboxes of task lists are allowed.
+func newUGCPolicy() *bluemonday.Policy {
+	policy := bluemonday.UGCPolicy()
+	policy.RequireNoFollowOnLinks(false)
+	policy.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	policy.AllowAttrs("checked", "disabled").OnElements("input")
+	return policy
+}
+
+// sanitizePolicy returns the policy used to sanitize rendered markdown, or nil
+// if the markdown should not be sanitized. Unknown names use the ugc policy.
+func sanitizePolicy(name string) *bluemonday.Policy {
+	switch name {
+	case sanitizeNone:
+		return nil
+	case sanitizeStrict:
+		return strictPolicy
+	default:
+		return ugcPolicy
+	}
+}
+
+// taskItemPattern matches the start of a task list item, e.g. "- [x] ".
+var taskItemPattern = regexp.MustCompile(`^(\s*[-*+]\s+)\[([ xX])\]\s`)
+
+// taskLists replaces the "[ ]" and "[x]" markers of task list items in the
+// markdown source with checkboxes.
+func taskLists(source string) string {
+	return mapLines(source, func(line string) string {
+		match := taskItemPattern.FindStringSubmatch(line)
+		if match == nil {
+			return line
+		}
+		checkbox := `<input type="checkbox" disabled> `
+		if match[2] != " " {
+			checkbox = `<input type="checkbox" checked disabled> `
+		}
+		return match[1] + checkbox + line[len(match[0]):]
+	})
+}
+
+// mapLines returns the markdown source with fn applied to each line which is
+// not part of a fenced code block.
+func mapLines(source string, fn func(line string) string) string {
+	var (
+		out    []string
+		fenced bool
+	)
+	for _, line := range strings.SplitAfter(source, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			fenced = !fenced
+			out = append(out, line)
+			continue
+		}
+		if fenced {
+			out = append(out, line)
+			continue
+		}
+		out = append(out, fn(line))
+	}
+	return strings.Join(out, "")
+}
+
+// typeNamePattern matches a (possibly dotted) identifier, e.g. "Foo" or
+// "Outer.Inner".
+var typeNamePattern = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*`)
+
+// autolinkSkipPattern matches the markdown and html which autolinkTypes leaves
+// unchanged: links with their text and target, link text of reference links,
+// html links with their content, html tags with their attributes, autolinks,
+// and bare URLs.
+var autolinkSkipPattern = regexp.MustCompile(
+	`\[[^\]]*\]\([^)]*\)|\[[^\]]*\]|(?is:<a\b[^>]*>.*?</a\s*>)|<[^>]*>|[A-Za-z][A-Za-z0-9+.-]*://[^\s<>()]*|www\.[^\s<>()]*`)
+
+// autolinkTypes replaces the type names in the markdown source with markdown
+// links to the documentation of the type. Names inside code spans, fenced
+// code blocks, links, html tags, and URLs are left unchanged, see
+// autolinkSkipPattern.
+func (f *tmplFuncs) autolinkTypes(source string) string {
+	symbols := f.knownSymbols()
+	if len(symbols) == 0 {
+		return source
+	}
+
+	linkNames := func(text string) string {
+		return typeNamePattern.ReplaceAllStringFunc(text, func(name string) string {
+			fullName, ok := symbols[name]
+			if !ok {
+				return name
+			}
+			url := f.typeURL(fullName)
+			if url == "" {
+				return name
+			}
+			return "[" + name + "](" + url + ")"
+		})
+	}
+	link := func(text string) string {
+		buf := new(bytes.Buffer)
+		last := 0
+		for _, loc := range autolinkSkipPattern.FindAllStringIndex(text, -1) {
+			buf.WriteString(linkNames(text[last:loc[0]]))
+			buf.WriteString(text[loc[0]:loc[1]])
+			last = loc[1]
+		}
+		buf.WriteString(linkNames(text[last:]))
+		return buf.String()
+	}
+
+	return mapLines(source, func(line string) string {
+		// Every odd element of the split is inside a code span.
+		spans := strings.Split(line, "`")
+		for i := 0; i < len(spans); i += 2 {
+			spans[i] = link(spans[i])
+		}
+		return strings.Join(spans, "`")
+	})
+}
+
+// knownSymbols returns a map of the names of all messages, enums, and services
+// in the request to their fully-qualified symbol paths. Package-relative names
+// resolve to the types in the package of the target file first, then to the
+// types in other packages. When more than one type has the same name the first
+// one declared is used. Names qualified by their package (without the leading
+// ".") are also included, so mentions of types from other packages resolve to
+// the exact type.
+func (f *tmplFuncs) knownSymbols() map[string]string {
+	files := make([]*descriptor.FileDescriptorProto, 0, len(f.protoFiles))
+	var others []*descriptor.FileDescriptorProto
+	for _, file := range f.protoFiles {
+		if f.protoFileDescriptor != nil && file.GetPackage() == f.protoFileDescriptor.GetPackage() {
+			files = append(files, file)
+			continue
+		}
+		others = append(others, file)
+	}
+	files = append(files, others...)
+
+	symbols := make(map[string]string)
+	for _, file := range files {
+		var names []string
+		for _, m := range util.AllMessages(file) {
+			names = append(names, m.GetName())
+		}
+		for _, e := range util.AllEnums(file) {
+			names = append(names, e.GetName())
+		}
+		for _, s := range file.GetService() {
+			names = append(names, s.GetName())
+		}
+		for _, name := range names {
+			fullName := util.FullName(file, name)
+			if _, ok := symbols[name]; !ok {
+				symbols[name] = fullName
+			}
+			symbols[strings.TrimPrefix(fullName, ".")] = fullName
+		}
+	}
+	return symbols
+}
+
+// packageOverview returns the rendered markdown overview of the package from
+// Config.PackageOverview, or an empty string if the package has no overview.
+func (f *tmplFuncs) packageOverview(packageName string) (template.HTML, error) {
+	name, ok := f.config.PackageOverview[packageName]
+	if !ok {
+		return "", nil
+	}
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(f.config.TemplateRoot, name)
+	}
+	source, err := ioutil.ReadFile(name)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read overview of package %s", packageName)
+	}
+	return f.markdown(string(source)), nil
+}
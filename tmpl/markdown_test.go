@@ -0,0 +1,209 @@
+package tmpl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func newAutolinkFile() *descriptor.FileDescriptorProto {
+	return &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+		MessageType: []*descriptor.DescriptorProto{
+			{Name: proto.String("Foo")},
+		},
+	}
+}
+
+func TestMarkdownAutolinkTypes(t *testing.T) {
+	f := newTestFuncs(Config{AutolinkTypes: true}, newAutolinkFile())
+	got := string(f.markdown("see Foo for details"))
+	expected := `<a href="foo.html#Foo">Foo</a>`
+	if !strings.Contains(got, expected) {
+		t.Fatalf("expected %q to contain %q", got, expected)
+	}
+}
+
+func TestMarkdownAutolinkTypesSkipsCodeSpans(t *testing.T) {
+	f := newTestFuncs(Config{AutolinkTypes: true}, newAutolinkFile())
+	got := string(f.markdown("see `Foo` for details"))
+	if strings.Contains(got, "<a ") {
+		t.Fatalf("expected no link in %q", got)
+	}
+	if !strings.Contains(got, "<code>Foo</code>") {
+		t.Fatalf("expected code span in %q", got)
+	}
+}
+
+func TestAutolinkTypesSkipsLinksAndHTML(t *testing.T) {
+	var testCases = []struct {
+		name   string
+		source string
+	}{
+		{name: "link text", source: "see [Foo](other.html) for details"},
+		{name: "reference link text", source: "see [the Foo][Foo] for details"},
+		{name: "link target", source: "see [the docs](https://example.com/Foo) for details"},
+		{name: "bare URL", source: "see https://example.com/foo.Foo for details"},
+		{name: "www URL", source: "see www.example.com/Foo for details"},
+		{name: "autolink", source: "see <https://example.com/Foo> for details"},
+		{name: "html attribute", source: `see <span title="Foo">this</span> for details`},
+		{name: "html link", source: `see <a href="#Foo">Foo</a> for details`},
+	}
+	f := newTestFuncs(Config{AutolinkTypes: true}, newAutolinkFile())
+	for _, testCase := range testCases {
+		if got := f.autolinkTypes(testCase.source); got != testCase.source {
+			t.Errorf("%s: got %q expected it unchanged", testCase.name, got)
+		}
+	}
+
+	got := f.autolinkTypes("see https://example.com/foo.Foo, and Foo")
+	expected := "see https://example.com/foo.Foo, and [Foo](foo.html#Foo)"
+	if got != expected {
+		t.Fatalf("got %q expected %q", got, expected)
+	}
+}
+
+func TestPackageOverview(t *testing.T) {
+	request := newTestRequest(&descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+	})
+	config := Config{
+		TemplateRoot:    testdataRoot(t),
+		PackageOverview: map[string]string{"foo": "foo-overview.md"},
+		Operations: []OperationConfig{
+			{Template: "package.html", Target: "foo.proto", Output: "foo.html"},
+		},
+	}
+	response, err := Generate(request, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Error != nil {
+		t.Fatalf("unexpected error: %s", response.GetError())
+	}
+	got := response.File[0].GetContent()
+	expected := "<p>The foo package does things.</p>"
+	if !strings.Contains(got, expected) {
+		t.Fatalf("expected %q to contain %q", got, expected)
+	}
+}
+
+func TestPackageOverviewMissing(t *testing.T) {
+	f := &tmplFuncs{}
+	got, err := f.packageOverview("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "" {
+		t.Fatalf("expected no overview, got %q", got)
+	}
+}
+
+func TestMarkdownTaskLists(t *testing.T) {
+	f := &tmplFuncs{config: Config{Markdown: MarkdownOptions{TaskLists: true}}}
+	got := string(f.markdown("Steps:\n\n- [ ] todo\n- [x] done\n"))
+	for _, expected := range []string{
+		`<li><input type="checkbox" disabled=""> todo</li>`,
+		`<li><input type="checkbox" checked="" disabled=""> done</li>`,
+	} {
+		if !strings.Contains(got, expected) {
+			t.Fatalf("expected %q to contain %q", got, expected)
+		}
+	}
+}
+
+func TestMarkdownDefinitionLists(t *testing.T) {
+	got := string((&tmplFuncs{}).markdown("Term\n: The definition.\n"))
+	for _, expected := range []string{"<dt>Term</dt>", "<dd>The definition.</dd>"} {
+		if !strings.Contains(got, expected) {
+			t.Fatalf("expected %q to contain %q", got, expected)
+		}
+	}
+}
+
+func TestMarkdownAutolinkTypesPrefersTargetPackage(t *testing.T) {
+	other := &descriptor.FileDescriptorProto{
+		Name:    proto.String("other.proto"),
+		Package: proto.String("other"),
+		MessageType: []*descriptor.DescriptorProto{
+			{Name: proto.String("Bar")},
+		},
+	}
+	file := &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+		MessageType: []*descriptor.DescriptorProto{
+			{Name: proto.String("Bar")},
+		},
+	}
+	f := &tmplFuncs{
+		protoFileDescriptor: file,
+		outputFile:          "foo.html",
+		protoFiles:          []*descriptor.FileDescriptorProto{other, file},
+		config:              Config{AutolinkTypes: true},
+	}
+
+	got := string(f.markdown("see Bar, not other.Bar"))
+	for _, expected := range []string{
+		`see <a href="foo.html#Bar">Bar</a>`,
+		`not <a href="other.html#Bar">other.Bar</a>`,
+	} {
+		if !strings.Contains(got, expected) {
+			t.Fatalf("expected %q to contain %q", got, expected)
+		}
+	}
+}
+
+func TestMarkdownSanitize(t *testing.T) {
+	source := "Click [here](javascript:void) or <script>alert(2)</script> **now**\n"
+
+	var testCases = []struct {
+		policy   string
+		expected string
+	}{
+		{policy: "", expected: "<p>Click here or  <strong>now</strong></p>\n"},
+		{policy: "ugc", expected: "<p>Click here or  <strong>now</strong></p>\n"},
+		{policy: "strict", expected: "Click here or  now\n"},
+		{
+			policy:   "none",
+			expected: "<p>Click <a href=\"javascript:void\">here</a> or <script>alert(2)</script> <strong>now</strong></p>\n",
+		},
+	}
+	for _, testCase := range testCases {
+		f := &tmplFuncs{config: Config{Markdown: MarkdownOptions{Sanitize: testCase.policy}}}
+		if got := string(f.markdown(source)); got != testCase.expected {
+			t.Errorf("%q: got %q expected %q", testCase.policy, got, testCase.expected)
+		}
+	}
+}
+
+func TestMarkdownCommentHeadingAnchors(t *testing.T) {
+	f := &tmplFuncs{config: Config{CommentHeadingAnchors: true}}
+	source := "# Title\n\n## Usage\n\nText\n\n### Usage\n"
+
+	got := string(f.markdown(source, "foo.Thing"))
+	expected := "<h1>Title</h1>\n\n" +
+		"<h2 id=\"foo-thing-usage\">Usage</h2>\n\n" +
+		"<p>Text</p>\n\n" +
+		"<h3 id=\"foo-thing-usage-1\">Usage</h3>\n"
+	if got != expected {
+		t.Fatalf("got %q expected %q", got, expected)
+	}
+
+	got = string(f.markdown("## x 1\n\n## x\n\n## x\n"))
+	expected = "<h2 id=\"x-1\">x 1</h2>\n\n" +
+		"<h2 id=\"x\">x</h2>\n\n" +
+		"<h2 id=\"x-2\">x</h2>\n"
+	if got != expected {
+		t.Fatalf("got %q expected %q", got, expected)
+	}
+
+	got = string(f.markdown("## Usage\n"))
+	if expected := "<h2 id=\"usage\">Usage</h2>\n"; got != expected {
+		t.Fatalf("got %q expected %q", got, expected)
+	}
+}
@@ -0,0 +1,28 @@
+package tmpl
+
+import "github.com/pkg/errors"
+
+// Integer helpers for templates, e.g. {{if eq (mod $i 2) 1}}odd{{end}}. The
+// builtin eq, lt, and gt functions of templates already compare integers.
+
+func add(a, b int) int { return a + b }
+
+func sub(a, b int) int { return a - b }
+
+func mul(a, b int) int { return a * b }
+
+func mod(a, b int) (int, error) {
+	if b == 0 {
+		return 0, errors.New("mod by zero")
+	}
+	return a % b, nil
+}
+
+// seq returns the integers from start up to, but not including, end.
+func seq(start, end int) []int {
+	var ints []int
+	for i := start; i < end; i++ {
+		ints = append(ints, i)
+	}
+	return ints
+}
@@ -0,0 +1,34 @@
+package tmpl
+
+import (
+	"bytes"
+	"html/template"
+	"testing"
+)
+
+func TestMathFuncs(t *testing.T) {
+	source := `{{add 2 3}} {{sub 2 3}} {{mul 2 3}} {{mod 7 3}} {{seq 1 4}}` +
+		`{{range $i := seq 0 4}} {{if eq (mod $i 2) 0}}even{{else}}odd{{end}}{{end}}` +
+		` {{if lt (add 1 1) 3}}lt{{end}} {{if gt (mul 2 2) 3}}gt{{end}}`
+	tmpl := template.Must(template.New("math").Funcs(newDefaultTemplateFuncs()).Parse(source))
+	buf := new(bytes.Buffer)
+	if err := tmpl.Execute(buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	expected := "5 -1 6 1 [1 2 3] even odd even odd lt gt"
+	if got := buf.String(); got != expected {
+		t.Fatalf("got %q expected %q", got, expected)
+	}
+}
+
+func TestModByZero(t *testing.T) {
+	if _, err := mod(1, 0); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestSeqEmpty(t *testing.T) {
+	if got := seq(3, 3); len(got) != 0 {
+		t.Fatalf("expected no ints, got %v", got)
+	}
+}
@@ -0,0 +1,87 @@
+package tmpl
+
+import (
+	"strings"
+
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// oneofGroup is a oneof of a message along with its member fields.
+type oneofGroup struct {
+	Oneof *descriptor.OneofDescriptorProto
+	// Index is the index of the oneof in the message, which is the OneofIndex
+	// of its members.
+	Index int32
+	Name  string
+	// Doc is the comment of the oneof.
+	Doc     string
+	Members []oneofMember
+}
+
+// oneofMember is a field which is a member of a oneof.
+type oneofMember struct {
+	Field *descriptor.FieldDescriptorProto
+	// Doc is the comment of the field, see fieldDoc.
+	Doc string
+}
+
+// oneofs returns each oneof declared in the message with its member fields, in
+// declaration order. The synthetic oneofs which protoc creates for proto3
+// optional fields are not included, see isSyntheticOneof.
+func (f *tmplFuncs) oneofs(msg *descriptor.DescriptorProto) []oneofGroup {
+	var groups []oneofGroup
+	for i, oneof := range msg.GetOneofDecl() {
+		index := int32(i)
+		if isSyntheticOneof(msg, index) {
+			continue
+		}
+		group := oneofGroup{
+			Oneof: oneof,
+			Index: index,
+			Name:  oneof.GetName(),
+			Doc:   f.fieldDoc(oneof),
+		}
+		for _, field := range oneofFields(msg, index) {
+			group.Members = append(group.Members, oneofMember{
+				Field: field,
+				Doc:   f.fieldDoc(field),
+			})
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// oneofFields returns the fields of the message which are members of the oneof
+// at index.
+func oneofFields(msg *descriptor.DescriptorProto, index int32) []*descriptor.FieldDescriptorProto {
+	var fields []*descriptor.FieldDescriptorProto
+	for _, field := range msg.GetField() {
+		if field.OneofIndex != nil && field.GetOneofIndex() == index {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// isSyntheticOneof returns true if the oneof at index was created by protoc for
+// a proto3 optional field, instead of being declared in the proto file.
+func isSyntheticOneof(msg *descriptor.DescriptorProto, index int32) bool {
+	for _, field := range oneofFields(msg, index) {
+		if proto3Optional(field) {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldDoc returns the comment of a field, or another element, with leading
+// and trailing whitespace removed. The leading comment is used if there is one,
+// otherwise the trailing comment.
+func (f *tmplFuncs) fieldDoc(x interface{}) string {
+	loc := f.location(x)
+	if comment := strings.TrimSpace(loc.GetLeadingComments()); comment != "" {
+		return comment
+	}
+	return strings.TrimSpace(loc.GetTrailingComments())
+}
@@ -0,0 +1,104 @@
+package tmpl
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestOneofsMemberComments(t *testing.T) {
+	file := &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+		MessageType: []*descriptor.DescriptorProto{
+			{
+				Name: proto.String("Shape"),
+				Field: []*descriptor.FieldDescriptorProto{
+					{Name: proto.String("name"), Number: proto.Int32(1)},
+					{Name: proto.String("circle"), Number: proto.Int32(2), OneofIndex: proto.Int32(0)},
+					{Name: proto.String("square"), Number: proto.Int32(3), OneofIndex: proto.Int32(0)},
+				},
+				OneofDecl: []*descriptor.OneofDescriptorProto{{Name: proto.String("kind")}},
+			},
+		},
+		SourceCodeInfo: &descriptor.SourceCodeInfo{
+			Location: []*descriptor.SourceCodeInfo_Location{
+				{Path: []int32{4, 0, 8, 0}, LeadingComments: proto.String(" The kind of shape.\n")},
+				{Path: []int32{4, 0, 2, 1}, LeadingComments: proto.String(" A round shape.\n")},
+				{Path: []int32{4, 0, 2, 2}, TrailingComments: proto.String(" A shape with four sides.\n")},
+			},
+		},
+	}
+	f := &tmplFuncs{protoFileDescriptor: file, protoFiles: []*descriptor.FileDescriptorProto{file}}
+
+	groups := f.oneofs(file.MessageType[0])
+	if len(groups) != 1 {
+		t.Fatalf("expected 1 oneof, got %d", len(groups))
+	}
+	if groups[0].Name != "kind" || groups[0].Doc != "The kind of shape." {
+		t.Fatalf("unexpected oneof %+v", groups[0])
+	}
+	var got []string
+	for _, member := range groups[0].Members {
+		got = append(got, member.Field.GetName()+": "+member.Doc)
+	}
+	expected := []string{"circle: A round shape.", "square: A shape with four sides."}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %v expected %v", got, expected)
+	}
+}
+
+func TestOneofsSkipsSyntheticOneofs(t *testing.T) {
+	field := func(name string, number int32, oneof *int32) *descriptor.FieldDescriptorProto {
+		return &descriptor.FieldDescriptorProto{
+			Name:       proto.String(name),
+			Number:     proto.Int32(number),
+			Label:      descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:       descriptor.FieldDescriptorProto_TYPE_INT32.Enum(),
+			OneofIndex: oneof,
+		}
+	}
+	optional := field("limit", 6, proto.Int32(1))
+	optional.XXX_unrecognized = []byte{0x88, 0x01, 0x01}
+	msg := &descriptor.DescriptorProto{
+		Name: proto.String("Query"),
+		Field: []*descriptor.FieldDescriptorProto{
+			field("id", 1, nil),
+			field("by_name", 2, proto.Int32(0)),
+			field("by_id", 3, proto.Int32(0)),
+			field("count", 4, nil),
+			field("ascending", 5, proto.Int32(2)),
+			optional,
+			field("descending", 7, proto.Int32(2)),
+		},
+		OneofDecl: []*descriptor.OneofDescriptorProto{
+			{Name: proto.String("filter")},
+			{Name: proto.String("_limit")},
+			{Name: proto.String("order")},
+		},
+	}
+	f := &tmplFuncs{}
+
+	var got []string
+	for _, group := range f.oneofs(msg) {
+		var members []string
+		for _, member := range group.Members {
+			members = append(members, member.Field.GetName())
+		}
+		got = append(got, fmt.Sprintf("%d %s %v", group.Index, group.Name, members))
+	}
+	expected := []string{"0 filter [by_name by_id]", "2 order [ascending descending]"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %v expected %v", got, expected)
+	}
+
+	if !isSyntheticOneof(msg, 1) {
+		t.Fatalf("expected oneof _limit to be synthetic")
+	}
+	if fields := oneofFields(msg, 1); len(fields) != 1 || fields[0] != optional {
+		t.Fatalf("unexpected fields of _limit %v", fields)
+	}
+}
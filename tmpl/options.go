@@ -0,0 +1,242 @@
+package tmpl
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// optionEntry is an option which is set on a descriptor node.
+type optionEntry struct {
+	Name  string
+	Value string
+}
+
+// optionsTable returns the options which are set on the descriptor node x.
+// Standard options are listed first in declaration order, followed by custom
+// options (registered extensions of the options message) ordered by field
+// number. Values are formatted by their type: strings are quoted, enums use
+// the value name, and messages are rendered as {key: value} pairs.
+func optionsTable(x interface{}) []optionEntry {
+	options := nodeOptions(x)
+	if options == nil {
+		return nil
+	}
+
+	var entries []optionEntry
+	protoFieldValues(reflect.ValueOf(options), func(name string, v reflect.Value) {
+		entries = append(entries, optionEntry{Name: name, Value: formatOptionValue(v)})
+	})
+
+	return append(entries, extensionOptions(options, false)...)
+}
+
+// customOptions returns the custom options which are set on the descriptor
+// node x, ordered by field number. Registered options are decoded like
+// optionsTable. Options which are not registered in the process can not be
+// decoded, so they are named by their field number, e.g. "(50001)", and their
+// value is the number for varint options, or the hex encoded bytes for other
+// options.
+func customOptions(x interface{}) []optionEntry {
+	options := nodeOptions(x)
+	if options == nil {
+		return nil
+	}
+	return extensionOptions(options, true)
+}
+
+// extensionOptions returns the extensions set on the options message, ordered
+// by field number. Extensions which are not registered are only included when
+// unknown is true.
+func extensionOptions(options proto.Message, unknown bool) []optionEntry {
+	descs, err := proto.ExtensionDescs(options)
+	if err != nil {
+		return nil
+	}
+	sort.Slice(descs, func(i, j int) bool { return descs[i].Field < descs[j].Field })
+
+	var entries []optionEntry
+	for _, desc := range descs {
+		if desc.ExtensionType == nil {
+			// not registered, so it can not be decoded
+			if unknown {
+				entries = append(entries, unknownOptions(options, desc.Field)...)
+			}
+			continue
+		}
+		value, err := proto.GetExtension(options, desc)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, optionEntry{
+			Name:  optionName(desc),
+			Value: formatOptionValue(reflect.ValueOf(value)),
+		})
+	}
+	return entries
+}
+
+// unknownOptions returns an entry for each value of the unregistered extension
+// field number, read from the encoded options.
+func unknownOptions(options proto.Message, number int32) []optionEntry {
+	data, err := proto.Marshal(options)
+	if err != nil {
+		return nil
+	}
+	name := fmt.Sprintf("(%d)", number)
+	var entries []optionEntry
+	unknownFields(data, func(key, value uint64, raw []byte) bool {
+		if key>>3 != uint64(number) {
+			return true
+		}
+		entry := optionEntry{Name: name, Value: strconv.FormatUint(value, 10)}
+		if key&0x7 == proto.WireBytes {
+			entry.Value = fmt.Sprintf("0x%x", raw)
+		}
+		entries = append(entries, entry)
+		return true
+	})
+	return entries
+}
+
+// optionsSummary returns the options which are set on the descriptor node x,
+// see optionsTable, as a collapsed html details block. An empty string is
+// returned if no options are set.
+func optionsSummary(x interface{}) template.HTML {
+	entries := optionsTable(x)
+	if len(entries) == 0 {
+		return ""
+	}
+	buf := new(bytes.Buffer)
+	buf.WriteString(`<details class="options"><summary>Options</summary><ul>`)
+	for _, entry := range entries {
+		fmt.Fprintf(buf, "<li><code>%s = %s</code></li>",
+			template.HTMLEscapeString(entry.Name), template.HTMLEscapeString(entry.Value))
+	}
+	buf.WriteString(`</ul></details>`)
+	return template.HTML(buf.String())
+}
+
+// optionName returns the name of a custom option as it is written in a proto
+// file, e.g. "(foo.bar)".
+func optionName(desc *proto.ExtensionDesc) string {
+	return "(" + desc.Name + ")"
+}
+
+// nodeOptions returns the options message of a descriptor node, or nil if the
+// node has no options set.
+func nodeOptions(x interface{}) proto.Message {
+	v := reflect.ValueOf(x)
+	if !v.IsValid() {
+		return nil
+	}
+	method := v.MethodByName("GetOptions")
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+		return nil
+	}
+	out := method.Call(nil)[0]
+	if out.IsNil() {
+		return nil
+	}
+	options, ok := out.Interface().(proto.Message)
+	if !ok {
+		return nil
+	}
+	return options
+}
+
+// protoFieldValues invokes fn with the proto name and value of each field of
+// the proto message which is set.
+func protoFieldValues(msg reflect.Value, fn func(name string, v reflect.Value)) {
+	indirect := reflect.Indirect(msg)
+	if indirect.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < indirect.NumField(); i++ {
+		name := protoTagName(indirect.Type().Field(i).Tag.Get("protobuf"))
+		if name == "" {
+			continue
+		}
+		field := indirect.Field(i)
+		if (field.Kind() == reflect.Ptr || field.Kind() == reflect.Slice) && field.IsNil() {
+			continue
+		}
+		fn(name, field)
+	}
+}
+
+// protoTagName returns the field name from a protobuf struct tag, for example
+// "foo" from "bytes,49,opt,name=foo,def=hello!".
+func protoTagName(tag string) string {
+	for _, part := range strings.Split(tag, ",") {
+		if strings.HasPrefix(part, "name=") {
+			return strings.TrimPrefix(part, "name=")
+		}
+	}
+	return ""
+}
+
+// formatOptionValue formats the value of an option by its type.
+func formatOptionValue(v reflect.Value) string {
+	if !v.IsValid() {
+		return ""
+	}
+	if stringer, ok := v.Interface().(fmt.Stringer); ok && isEnum(v) {
+		return stringer.String()
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return ""
+		}
+		if _, ok := v.Interface().(proto.Message); ok {
+			var fields []string
+			protoFieldValues(v, func(name string, field reflect.Value) {
+				fields = append(fields, name+": "+formatOptionValue(field))
+			})
+			return "{" + strings.Join(fields, ", ") + "}"
+		}
+		return formatOptionValue(v.Elem())
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return strconv.Quote(string(v.Bytes()))
+		}
+		var items []string
+		for i := 0; i < v.Len(); i++ {
+			items = append(items, formatOptionValue(v.Index(i)))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case reflect.String:
+		return strconv.Quote(v.String())
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64)
+	case reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	default:
+		return fmt.Sprintf("%v", v.Interface())
+	}
+}
+
+// isEnum returns true if the value is a generated enum type, or a pointer to
+// one.
+func isEnum(v reflect.Value) bool {
+	t := v.Type()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	_, ok := reflect.New(t).Interface().(interface {
+		EnumDescriptor() ([]byte, []int)
+	})
+	return ok
+}
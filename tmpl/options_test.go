@@ -0,0 +1,142 @@
+package tmpl
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// testMetaOption is a custom message option with a message value.
+var testMetaOption = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MessageOptions)(nil),
+	ExtensionType: (*descriptor.UninterpretedOption_NamePart)(nil),
+	Field:         51234,
+	Name:          "test.meta",
+	Tag:           "bytes,51234,opt,name=meta",
+}
+
+func init() {
+	proto.RegisterExtension(testMetaOption)
+}
+
+func TestOptionsTableCustomMessageOption(t *testing.T) {
+	options := &descriptor.MessageOptions{Deprecated: proto.Bool(true)}
+	err := proto.SetExtension(options, testMetaOption, &descriptor.UninterpretedOption_NamePart{
+		NamePart:    proto.String("foo"),
+		IsExtension: proto.Bool(false),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Round trip the options to match what is received from protoc.
+	data, err := proto.Marshal(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := &descriptor.DescriptorProto{
+		Name:    proto.String("Foo"),
+		Options: &descriptor.MessageOptions{},
+	}
+	if err := proto.Unmarshal(data, msg.Options); err != nil {
+		t.Fatal(err)
+	}
+
+	got := optionsTable(msg)
+	expected := []optionEntry{
+		{Name: "deprecated", Value: "true"},
+		{Name: "(test.meta)", Value: `{name_part: "foo", is_extension: false}`},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %+v expected %+v", got, expected)
+	}
+}
+
+func TestOptionsTableEnumAndStringOptions(t *testing.T) {
+	file := &descriptor.FileDescriptorProto{
+		Name: proto.String("foo.proto"),
+		Options: &descriptor.FileOptions{
+			GoPackage:   proto.String("example.com/foo"),
+			OptimizeFor: descriptor.FileOptions_CODE_SIZE.Enum(),
+		},
+	}
+	got := optionsTable(file)
+	expected := []optionEntry{
+		{Name: "optimize_for", Value: "CODE_SIZE"},
+		{Name: "go_package", Value: `"example.com/foo"`},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %+v expected %+v", got, expected)
+	}
+}
+
+func TestOptionsTableNoOptions(t *testing.T) {
+	if got := optionsTable(&descriptor.DescriptorProto{}); got != nil {
+		t.Fatalf("expected no options, got %+v", got)
+	}
+}
+
+func TestOptionsSummary(t *testing.T) {
+	enum := &descriptor.EnumDescriptorProto{
+		Name:    proto.String("Kind"),
+		Options: &descriptor.EnumOptions{AllowAlias: proto.Bool(true), Deprecated: proto.Bool(true)},
+	}
+	got := string(optionsSummary(enum))
+	expected := `<details class="options"><summary>Options</summary><ul>` +
+		`<li><code>allow_alias = true</code></li>` +
+		`<li><code>deprecated = true</code></li>` +
+		`</ul></details>`
+	if got != expected {
+		t.Fatalf("got %q expected %q", got, expected)
+	}
+
+	msg := &descriptor.DescriptorProto{Name: proto.String("Foo")}
+	if got := optionsSummary(msg); got != "" {
+		t.Fatalf("expected no details block, got %q", got)
+	}
+}
+
+func TestCustomOptions(t *testing.T) {
+	options := &descriptor.MessageOptions{Deprecated: proto.Bool(true)}
+	err := proto.SetExtension(options, testMetaOption, &descriptor.UninterpretedOption_NamePart{
+		NamePart:    proto.String("foo"),
+		IsExtension: proto.Bool(false),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := proto.Marshal(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Add options which are not registered: a varint 50001 and bytes 50002.
+	buf := proto.NewBuffer(data)
+	buf.EncodeVarint(50001<<3 | proto.WireVarint)
+	buf.EncodeVarint(7)
+	buf.EncodeVarint(50002<<3 | proto.WireBytes)
+	buf.EncodeStringBytes("\x01\x02")
+
+	msg := &descriptor.DescriptorProto{
+		Name:    proto.String("Foo"),
+		Options: &descriptor.MessageOptions{},
+	}
+	if err := proto.Unmarshal(buf.Bytes(), msg.Options); err != nil {
+		t.Fatal(err)
+	}
+
+	got := customOptions(msg)
+	expected := []optionEntry{
+		{Name: "(50001)", Value: "7"},
+		{Name: "(50002)", Value: "0x0102"},
+		{Name: "(test.meta)", Value: `{name_part: "foo", is_extension: false}`},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %+v expected %+v", got, expected)
+	}
+
+	// optionsTable only includes the registered options.
+	if got := optionsTable(msg); len(got) != 2 {
+		t.Fatalf("expected 2 options, got %+v", got)
+	}
+}
@@ -0,0 +1,67 @@
+package tmpl
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// preamble returns the text formatted as a comment for the type of the output
+// file, or an empty string if the text is empty or the file type has no
+// comment syntax.
+func preamble(text, output string) string {
+	if text == "" {
+		return ""
+	}
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+
+	switch strings.ToLower(path.Ext(output)) {
+	case ".html", ".htm", ".xml", ".svg", ".md", ".markdown":
+		// "--" is not allowed inside of an HTML comment.
+		body := strings.Replace(strings.Join(lines, "\n"), "--", "- -", -1)
+		return "<!--\n" + body + "\n-->\n"
+	case ".css":
+		return "/*\n" + strings.Join(lines, "\n") + "\n*/\n"
+	case ".go", ".js", ".ts", ".proto", ".java", ".c", ".h", ".cc", ".cpp":
+		return linePrefix("// ", lines)
+	case ".txt", ".yaml", ".yml", ".toml", ".sh", ".py", ".rb":
+		return linePrefix("# ", lines)
+	default:
+		return ""
+	}
+}
+
+// leadingDeclPatterns match the XML declaration and the doctype, which must
+// stay at the start of the content, in the order they are written.
+var leadingDeclPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^<\?xml[^>]*\?>`),
+	regexp.MustCompile(`^\s*<!(?i:doctype)[^>]*>`),
+}
+
+// insertPreamble returns the content with the comment returned by preamble
+// inserted at the start, or after the XML declaration and doctype when the
+// content starts with them.
+func insertPreamble(comment, content string) string {
+	if comment == "" {
+		return content
+	}
+	end := 0
+	for _, pattern := range leadingDeclPatterns {
+		if loc := pattern.FindStringIndex(content[end:]); loc != nil {
+			end += loc[1]
+		}
+	}
+	if end == 0 {
+		return comment + content
+	}
+	rest := strings.TrimPrefix(content[end:], "\n")
+	return content[:end] + "\n" + comment + rest
+}
+
+func linePrefix(prefix string, lines []string) string {
+	var out string
+	for _, line := range lines {
+		out += strings.TrimRight(prefix+line, " ") + "\n"
+	}
+	return out
+}
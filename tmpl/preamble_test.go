@@ -0,0 +1,70 @@
+package tmpl
+
+import (
+	"testing"
+)
+
+const testPreamble = "Code generated by protoc-gen-html. DO NOT EDIT."
+
+func TestPreambleHTML(t *testing.T) {
+	expected := "<!--\nCode generated by protoc-gen-html. DO NOT EDIT.\n-->\n"
+	if got := preamble(testPreamble, "foo/index.html"); got != expected {
+		t.Fatalf("got %q expected %q", got, expected)
+	}
+}
+
+func TestPreambleMarkdown(t *testing.T) {
+	expected := "<!--\nCode generated by protoc-gen-html. DO NOT EDIT.\n-->\n"
+	if got := preamble(testPreamble, "README.md"); got != expected {
+		t.Fatalf("got %q expected %q", got, expected)
+	}
+}
+
+func TestPreambleLineComments(t *testing.T) {
+	expected := "# first\n#\n# second\n"
+	if got := preamble("first\n\nsecond\n", "checksums.txt"); got != expected {
+		t.Fatalf("got %q expected %q", got, expected)
+	}
+}
+
+func TestPreambleNoCommentSyntax(t *testing.T) {
+	if got := preamble(testPreamble, "manifest.json"); got != "" {
+		t.Fatalf("expected no preamble for json, got %q", got)
+	}
+}
+
+func TestInsertPreamble(t *testing.T) {
+	comment := preamble(testPreamble, "index.html")
+	var testCases = []struct {
+		name, content, expected string
+	}{
+		{
+			name:     "no declaration",
+			content:  "<html></html>\n",
+			expected: comment + "<html></html>\n",
+		},
+		{
+			name:     "xml declaration",
+			content:  `<?xml version="1.0" encoding="UTF-8"?>` + "\n<svg></svg>\n",
+			expected: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + comment + "<svg></svg>\n",
+		},
+		{
+			name:     "doctype",
+			content:  "<!DOCTYPE html><html></html>\n",
+			expected: "<!DOCTYPE html>\n" + comment + "<html></html>\n",
+		},
+		{
+			name:     "xml declaration and doctype",
+			content:  "<?xml version=\"1.0\"?>\n<!doctype html>\n<html></html>\n",
+			expected: "<?xml version=\"1.0\"?>\n<!doctype html>\n" + comment + "<html></html>\n",
+		},
+	}
+	for _, testCase := range testCases {
+		if got := insertPreamble(comment, testCase.content); got != testCase.expected {
+			t.Errorf("%s: got %q expected %q", testCase.name, got, testCase.expected)
+		}
+	}
+	if got := insertPreamble("", "<?xml?>"); got != "<?xml?>" {
+		t.Errorf("expected the content unchanged without a preamble, got %q", got)
+	}
+}
@@ -0,0 +1,392 @@
+package tmpl
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// reconstructProto returns best-effort proto source for the file, rebuilt from
+// its descriptor. Comments and the order of declarations of different kinds
+// are not in the descriptor, so top-level messages are written first,
+// followed by enums, extensions, and services. Types from the package of the
+// file are referenced by their package-relative name, and other types by their
+// fully-qualified name.
+func (f *tmplFuncs) reconstructProto(file *descriptor.FileDescriptorProto) string {
+	w := &protoWriter{funcs: f, file: file, buf: new(bytes.Buffer)}
+	w.writeFile()
+	return w.buf.String()
+}
+
+// definitionWithComments returns the proto source of the message, rebuilt from
+// its descriptor, with the comment of each field and oneof written above its
+// declaration, as it is in the original proto file.
+func (f *tmplFuncs) definitionWithComments(msg *descriptor.DescriptorProto) string {
+	file := f.protoFileDescriptor
+	if len(msg.GetField()) > 0 {
+		file = f.fieldFile(msg.GetField()[0])
+	}
+	w := &protoWriter{funcs: f, file: file, buf: new(bytes.Buffer), comments: true}
+	w.writeMessage(msg)
+	return w.buf.String()
+}
+
+// protoWriter writes the proto source of the declarations of a file.
+type protoWriter struct {
+	funcs *tmplFuncs
+	file  *descriptor.FileDescriptorProto
+	buf   *bytes.Buffer
+	depth int
+	// comments writes the comments of fields and oneofs above their
+	// declarations.
+	comments bool
+}
+
+func (w *protoWriter) line(format string, args ...interface{}) {
+	w.buf.WriteString(strings.Repeat("  ", w.depth))
+	fmt.Fprintf(w.buf, format, args...)
+	w.buf.WriteString("\n")
+}
+
+func (w *protoWriter) writeFile() {
+	file := w.file
+	w.line("syntax = %q;", syntax(file))
+	if file.GetPackage() != "" {
+		w.buf.WriteString("\n")
+		w.line("package %s;", file.GetPackage())
+	}
+
+	if len(file.GetDependency()) > 0 {
+		w.buf.WriteString("\n")
+	}
+	for i, dep := range file.GetDependency() {
+		w.line("import %s%q;", importModifier(file, int32(i)), dep)
+	}
+
+	if options := optionsTable(file); len(options) > 0 {
+		w.buf.WriteString("\n")
+		w.writeOptions(options)
+	}
+
+	for _, msg := range w.funcs.filterMessages(file.GetMessageType()) {
+		w.buf.WriteString("\n")
+		w.writeMessage(msg)
+	}
+	for _, enum := range w.funcs.filterEnums(file.GetEnumType()) {
+		w.buf.WriteString("\n")
+		w.writeEnum(enum)
+	}
+	w.writeExtensions(file.GetExtension())
+	for _, service := range w.funcs.services(file) {
+		w.buf.WriteString("\n")
+		w.writeService(service)
+	}
+}
+
+// importModifier returns the "public " or "weak " modifier of the dependency
+// of the file at index.
+func importModifier(file *descriptor.FileDescriptorProto, index int32) string {
+	for _, i := range file.GetPublicDependency() {
+		if i == index {
+			return "public "
+		}
+	}
+	for _, i := range file.GetWeakDependency() {
+		if i == index {
+			return "weak "
+		}
+	}
+	return ""
+}
+
+func (w *protoWriter) writeOptions(options []optionEntry) {
+	for _, option := range options {
+		w.line("option %s = %s;", option.Name, option.Value)
+	}
+}
+
+func (w *protoWriter) writeMessage(msg *descriptor.DescriptorProto) {
+	w.line("message %s {", msg.GetName())
+	w.depth++
+	w.writeOptions(optionsTable(msg))
+
+	written := make(map[int32]bool)
+	for _, field := range w.funcs.fields(msg) {
+		if field.OneofIndex == nil || proto3Optional(field) {
+			w.writeField(field, true)
+			continue
+		}
+		index := field.GetOneofIndex()
+		if written[index] || int(index) >= len(msg.GetOneofDecl()) {
+			continue
+		}
+		written[index] = true
+		w.writeOneof(msg, index)
+	}
+
+	for _, nested := range w.funcs.filterMessages(msg.GetNestedType()) {
+		if nested.GetOptions().GetMapEntry() {
+			continue
+		}
+		w.writeMessage(nested)
+	}
+	for _, enum := range w.funcs.filterEnums(msg.GetEnumType()) {
+		w.writeEnum(enum)
+	}
+	w.writeExtensions(msg.GetExtension())
+	w.writeRanges("extensions", messageExtensionRanges(msg), maxFieldNumber)
+	w.writeReserved(messageReservedRanges(msg), msg.GetReservedName(), maxFieldNumber)
+	w.depth--
+	w.line("}")
+}
+
+func (w *protoWriter) writeOneof(msg *descriptor.DescriptorProto, index int32) {
+	w.writeComment(msg.GetOneofDecl()[index])
+	w.line("oneof %s {", msg.GetOneofDecl()[index].GetName())
+	w.depth++
+	for _, field := range oneofFields(msg, index) {
+		w.writeField(field, false)
+	}
+	w.depth--
+	w.line("}")
+}
+
+func (w *protoWriter) writeField(field *descriptor.FieldDescriptorProto, withLabel bool) {
+	w.writeComment(field)
+	w.line("%s", w.fieldDeclaration(field, withLabel))
+}
+
+// writeComment writes the comment of the element, see fieldDoc, as "//" line
+// comments when comments are enabled.
+func (w *protoWriter) writeComment(x interface{}) {
+	if !w.comments {
+		return
+	}
+	doc := w.funcs.fieldDoc(x)
+	if doc == "" {
+		return
+	}
+	for _, line := range strings.Split(doc, "\n") {
+		w.line("%s", strings.TrimRight("// "+strings.TrimSpace(line), " "))
+	}
+}
+
+// fieldDeclaration returns the declaration of the field, e.g.
+// "repeated string names = 1;". The label is omitted for members of a oneof.
+func (w *protoWriter) fieldDeclaration(field *descriptor.FieldDescriptorProto, withLabel bool) string {
+	var typeName string
+	if entry := w.funcs.mapEntry(field); entry != nil {
+		typeName = fmt.Sprintf("map<%s, %s>", w.typeName(entry.Key), w.typeName(entry.Value))
+		withLabel = false
+	} else {
+		typeName = w.typeName(field)
+	}
+
+	label := ""
+	if withLabel {
+		label = w.label(field)
+	}
+	return fmt.Sprintf("%s%s %s = %d%s;",
+		label, typeName, field.GetName(), field.GetNumber(), fieldOptions(field))
+}
+
+func (w *protoWriter) label(field *descriptor.FieldDescriptorProto) string {
+	return fieldLabel(w.file, field)
+}
+
+// fieldLabel returns the label of the field as it is written in the syntax of
+// the file, followed by a space, or an empty string if the field has no label.
+// Fields of proto3 files only have a label when they are repeated or declared
+// optional.
+func fieldLabel(file *descriptor.FileDescriptorProto, field *descriptor.FieldDescriptorProto) string {
+	if field.Label == nil {
+		return ""
+	}
+	if file.GetSyntax() == "proto3" {
+		switch {
+		case field.GetLabel() == descriptor.FieldDescriptorProto_LABEL_REPEATED:
+			return "repeated "
+		case proto3Optional(field):
+			return "optional "
+		default:
+			return ""
+		}
+	}
+	return labelString(field.Label) + " "
+}
+
+// typeName returns the type of the field, relative to the package of the file
+// if the type is declared in the same package.
+func (w *protoWriter) typeName(field *descriptor.FieldDescriptorProto) string {
+	if field.TypeName == nil {
+		return fieldType(field)
+	}
+	name := field.GetTypeName()
+	if pkg := w.file.GetPackage(); pkg != "" && strings.HasPrefix(name, "."+pkg+".") {
+		return strings.TrimPrefix(name, "."+pkg+".")
+	}
+	return name
+}
+
+// fieldOptions returns the options of the field in brackets, e.g.
+// " [default = 3, deprecated = true]", or an empty string if it has none.
+// Defaults of bytes fields are already escaped by protoc, so they are quoted
+// without escaping them again.
+func fieldOptions(field *descriptor.FieldDescriptorProto) string {
+	var options []string
+	if field.DefaultValue != nil {
+		value := field.GetDefaultValue()
+		switch field.GetType() {
+		case descriptor.FieldDescriptorProto_TYPE_STRING:
+			value = strconv.Quote(value)
+		case descriptor.FieldDescriptorProto_TYPE_BYTES:
+			value = `"` + value + `"`
+		}
+		options = append(options, "default = "+value)
+	}
+	if field.JsonName != nil && field.GetJsonName() != jsonName(&descriptor.FieldDescriptorProto{Name: field.Name}) {
+		options = append(options, fmt.Sprintf("json_name = %q", field.GetJsonName()))
+	}
+	for _, option := range optionsTable(field) {
+		options = append(options, option.Name+" = "+option.Value)
+	}
+	if len(options) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(options, ", ") + "]"
+}
+
+func (w *protoWriter) writeEnum(enum *descriptor.EnumDescriptorProto) {
+	w.line("enum %s {", enum.GetName())
+	w.depth++
+	w.writeOptions(optionsTable(enum))
+	for _, value := range w.funcs.enumValues(enum) {
+		var options []string
+		for _, option := range optionsTable(value) {
+			options = append(options, option.Name+" = "+option.Value)
+		}
+		suffix := ""
+		if len(options) > 0 {
+			suffix = " [" + strings.Join(options, ", ") + "]"
+		}
+		w.line("%s = %d%s;", value.GetName(), value.GetNumber(), suffix)
+	}
+	w.writeReserved(enumReservedRanges(enum), enumReservedNames(enum), math.MaxInt32)
+	w.depth--
+	w.line("}")
+}
+
+// writeExtensions writes the extensions grouped by the message they extend,
+// in the order the extended messages are first found.
+func (w *protoWriter) writeExtensions(extensions []*descriptor.FieldDescriptorProto) {
+	var extendees []string
+	byExtendee := make(map[string][]*descriptor.FieldDescriptorProto)
+	for _, ext := range extensions {
+		if _, ok := byExtendee[ext.GetExtendee()]; !ok {
+			extendees = append(extendees, ext.GetExtendee())
+		}
+		byExtendee[ext.GetExtendee()] = append(byExtendee[ext.GetExtendee()], ext)
+	}
+
+	for _, extendee := range extendees {
+		if w.depth == 0 {
+			w.buf.WriteString("\n")
+		}
+		w.line("extend %s {", w.typeName(&descriptor.FieldDescriptorProto{TypeName: &extendee}))
+		w.depth++
+		for _, ext := range byExtendee[extendee] {
+			w.writeField(ext, true)
+		}
+		w.depth--
+		w.line("}")
+	}
+}
+
+// reservedRange is a range of reserved or extension numbers, with an exclusive
+// End.
+type reservedRange struct {
+	Start int32
+	End   int32
+}
+
+func messageReservedRanges(msg *descriptor.DescriptorProto) []reservedRange {
+	var ranges []reservedRange
+	for _, r := range msg.GetReservedRange() {
+		ranges = append(ranges, reservedRange{Start: r.GetStart(), End: r.GetEnd()})
+	}
+	return ranges
+}
+
+func messageExtensionRanges(msg *descriptor.DescriptorProto) []reservedRange {
+	var ranges []reservedRange
+	for _, r := range msg.GetExtensionRange() {
+		ranges = append(ranges, reservedRange{Start: r.GetStart(), End: r.GetEnd()})
+	}
+	return ranges
+}
+
+// writeRanges writes the ranges as a statement starting with the keyword, e.g.
+// "extensions 100 to max;". Ranges which end at max are written "to max".
+func (w *protoWriter) writeRanges(keyword string, ranges []reservedRange, max int32) {
+	if len(ranges) == 0 {
+		return
+	}
+	var items []string
+	for _, r := range ranges {
+		// The End of a range which ends at math.MaxInt32 overflows, so the
+		// inclusive end is compared instead.
+		last := r.End - 1
+		switch {
+		case last == r.Start:
+			items = append(items, strconv.Itoa(int(r.Start)))
+		case last == max:
+			items = append(items, fmt.Sprintf("%d to max", r.Start))
+		default:
+			items = append(items, fmt.Sprintf("%d to %d", r.Start, last))
+		}
+	}
+	w.line("%s %s;", keyword, strings.Join(items, ", "))
+}
+
+func (w *protoWriter) writeReserved(ranges []reservedRange, names []string, max int32) {
+	w.writeRanges("reserved", ranges, max)
+	if len(names) > 0 {
+		var items []string
+		for _, name := range names {
+			items = append(items, strconv.Quote(name))
+		}
+		w.line("reserved %s;", strings.Join(items, ", "))
+	}
+}
+
+// maxFieldNumber is the largest valid field number.
+const maxFieldNumber = 1<<29 - 1
+
+func (w *protoWriter) writeService(service *descriptor.ServiceDescriptorProto) {
+	w.line("service %s {", service.GetName())
+	w.depth++
+	w.writeOptions(optionsTable(service))
+	for _, method := range w.funcs.methods(service) {
+		declaration := fmt.Sprintf("rpc %s(%s%s) returns (%s%s)",
+			method.GetName(),
+			streamPrefix(method.GetClientStreaming()), w.typeName(&descriptor.FieldDescriptorProto{TypeName: method.InputType}),
+			streamPrefix(method.GetServerStreaming()), w.typeName(&descriptor.FieldDescriptorProto{TypeName: method.OutputType}))
+
+		options := optionsTable(method)
+		if len(options) == 0 {
+			w.line("%s;", declaration)
+			continue
+		}
+		w.line("%s {", declaration)
+		w.depth++
+		w.writeOptions(options)
+		w.depth--
+		w.line("}")
+	}
+	w.depth--
+	w.line("}")
+}
@@ -0,0 +1,335 @@
+package tmpl
+
+import (
+	"math"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestReconstructProto(t *testing.T) {
+	optional := &descriptor.FieldDescriptorProto{
+		Name:       proto.String("nickname"),
+		Number:     proto.Int32(4),
+		Label:      descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:       descriptor.FieldDescriptorProto_TYPE_STRING.Enum(),
+		OneofIndex: proto.Int32(1),
+	}
+	optional.XXX_unrecognized = []byte{0x88, 0x01, 0x01}
+
+	file := &descriptor.FileDescriptorProto{
+		Name:       proto.String("foo/foo.proto"),
+		Package:    proto.String("foo"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/empty.proto"},
+		Options:    &descriptor.FileOptions{GoPackage: proto.String("example.com/foo;foopb")},
+		MessageType: []*descriptor.DescriptorProto{
+			{
+				Name: proto.String("User"),
+				Field: []*descriptor.FieldDescriptorProto{
+					{
+						Name:     proto.String("tags"),
+						Number:   proto.Int32(1),
+						Label:    descriptor.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+						Type:     descriptor.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+						TypeName: proto.String(".foo.User.TagsEntry"),
+					},
+					{
+						Name:       proto.String("email"),
+						Number:     proto.Int32(2),
+						Label:      descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+						Type:       descriptor.FieldDescriptorProto_TYPE_STRING.Enum(),
+						OneofIndex: proto.Int32(0),
+					},
+					{
+						Name:       proto.String("phone"),
+						Number:     proto.Int32(3),
+						Label:      descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+						Type:       descriptor.FieldDescriptorProto_TYPE_STRING.Enum(),
+						OneofIndex: proto.Int32(0),
+					},
+					optional,
+					{
+						Name:     proto.String("kinds"),
+						Number:   proto.Int32(5),
+						Label:    descriptor.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+						Type:     descriptor.FieldDescriptorProto_TYPE_ENUM.Enum(),
+						TypeName: proto.String(".foo.Kind"),
+						Options:  &descriptor.FieldOptions{Deprecated: proto.Bool(true)},
+					},
+				},
+				NestedType: []*descriptor.DescriptorProto{
+					{
+						Name: proto.String("TagsEntry"),
+						Field: []*descriptor.FieldDescriptorProto{
+							{
+								Name:   proto.String("key"),
+								Number: proto.Int32(1),
+								Label:  descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+								Type:   descriptor.FieldDescriptorProto_TYPE_STRING.Enum(),
+							},
+							{
+								Name:   proto.String("value"),
+								Number: proto.Int32(2),
+								Label:  descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+								Type:   descriptor.FieldDescriptorProto_TYPE_INT32.Enum(),
+							},
+						},
+						Options: &descriptor.MessageOptions{MapEntry: proto.Bool(true)},
+					},
+					{Name: proto.String("Address")},
+				},
+				OneofDecl: []*descriptor.OneofDescriptorProto{
+					{Name: proto.String("contact")},
+					{Name: proto.String("_nickname")},
+				},
+				ReservedRange: []*descriptor.DescriptorProto_ReservedRange{
+					{Start: proto.Int32(8), End: proto.Int32(9)},
+					{Start: proto.Int32(10), End: proto.Int32(13)},
+				},
+				ReservedName: []string{"old"},
+			},
+		},
+		EnumType: []*descriptor.EnumDescriptorProto{
+			{
+				Name:  proto.String("Kind"),
+				Value: []*descriptor.EnumValueDescriptorProto{enumValue("KIND_UNKNOWN", 0), enumValue("KIND_ADMIN", 1)},
+			},
+		},
+		Service: []*descriptor.ServiceDescriptorProto{
+			{
+				Name: proto.String("Users"),
+				Method: []*descriptor.MethodDescriptorProto{
+					{
+						Name:            proto.String("Watch"),
+						InputType:       proto.String(".google.protobuf.Empty"),
+						OutputType:      proto.String(".foo.User"),
+						ServerStreaming: proto.Bool(true),
+					},
+				},
+			},
+		},
+	}
+	f := &tmplFuncs{protoFiles: []*descriptor.FileDescriptorProto{file}}
+
+	expected := `syntax = "proto3";
+
+package foo;
+
+import "google/protobuf/empty.proto";
+
+option go_package = "example.com/foo;foopb";
+
+message User {
+  map<string, int32> tags = 1;
+  oneof contact {
+    string email = 2;
+    string phone = 3;
+  }
+  optional string nickname = 4;
+  repeated Kind kinds = 5 [deprecated = true];
+  message Address {
+  }
+  reserved 8, 10 to 12;
+  reserved "old";
+}
+
+enum Kind {
+  KIND_UNKNOWN = 0;
+  KIND_ADMIN = 1;
+}
+
+service Users {
+  rpc Watch(.google.protobuf.Empty) returns (stream User);
+}
+`
+	if got := f.reconstructProto(file); got != expected {
+		t.Fatalf("got:\n%s\nexpected:\n%s", got, expected)
+	}
+}
+
+func TestReconstructProtoCanonicalHideDeprecated(t *testing.T) {
+	file := &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptor.DescriptorProto{
+			{
+				Name:    proto.String("Old"),
+				Options: &descriptor.MessageOptions{Deprecated: proto.Bool(true)},
+			},
+			{
+				Name: proto.String("User"),
+				Field: []*descriptor.FieldDescriptorProto{
+					{
+						Name:   proto.String("name"),
+						Number: proto.Int32(2),
+						Label:  descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+						Type:   descriptor.FieldDescriptorProto_TYPE_STRING.Enum(),
+					},
+					{
+						Name:   proto.String("id"),
+						Number: proto.Int32(1),
+						Label:  descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+						Type:   descriptor.FieldDescriptorProto_TYPE_INT64.Enum(),
+					},
+				},
+			},
+		},
+		EnumType: []*descriptor.EnumDescriptorProto{
+			{
+				Name:  proto.String("Kind"),
+				Value: []*descriptor.EnumValueDescriptorProto{enumValue("KIND_ADMIN", 1), enumValue("KIND_UNKNOWN", 0)},
+			},
+		},
+		Service: []*descriptor.ServiceDescriptorProto{
+			{
+				Name:    proto.String("Legacy"),
+				Options: &descriptor.ServiceOptions{Deprecated: proto.Bool(true)},
+			},
+		},
+	}
+	f := &tmplFuncs{
+		protoFiles: []*descriptor.FileDescriptorProto{file},
+		config:     Config{Canonical: true, HideDeprecated: true},
+	}
+
+	expected := `syntax = "proto3";
+
+package foo;
+
+message User {
+  int64 id = 1;
+  string name = 2;
+}
+
+enum Kind {
+  KIND_UNKNOWN = 0;
+  KIND_ADMIN = 1;
+}
+`
+	if got := f.reconstructProto(file); got != expected {
+		t.Fatalf("got:\n%s\nexpected:\n%s", got, expected)
+	}
+}
+
+func TestReconstructProtoRanges(t *testing.T) {
+	file := &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+		Syntax:  proto.String("proto2"),
+		MessageType: []*descriptor.DescriptorProto{
+			{
+				Name: proto.String("Extendable"),
+				ExtensionRange: []*descriptor.DescriptorProto_ExtensionRange{
+					{Start: proto.Int32(5), End: proto.Int32(6)},
+					{Start: proto.Int32(100), End: proto.Int32(200)},
+					{Start: proto.Int32(1000), End: proto.Int32(maxFieldNumber + 1)},
+				},
+			},
+		},
+		EnumType: []*descriptor.EnumDescriptorProto{
+			{
+				Name:             proto.String("Kind"),
+				Value:            []*descriptor.EnumValueDescriptorProto{enumValue("KIND_UNKNOWN", 0)},
+				XXX_unrecognized: encodeEnumReserved([][2]int64{{2, 2}, {9, 11}, {100, math.MaxInt32}}, "OLD"),
+			},
+		},
+	}
+	f := &tmplFuncs{protoFiles: []*descriptor.FileDescriptorProto{file}}
+
+	expected := `syntax = "proto2";
+
+package foo;
+
+message Extendable {
+  extensions 5, 100 to 199, 1000 to max;
+}
+
+enum Kind {
+  KIND_UNKNOWN = 0;
+  reserved 2, 9 to 11, 100 to max;
+  reserved "OLD";
+}
+`
+	if got := f.reconstructProto(file); got != expected {
+		t.Fatalf("got:\n%s\nexpected:\n%s", got, expected)
+	}
+}
+
+func TestDefinitionWithComments(t *testing.T) {
+	file := &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptor.DescriptorProto{
+			{
+				Name: proto.String("Foo"),
+				Field: []*descriptor.FieldDescriptorProto{
+					{
+						Name:   proto.String("id"),
+						Number: proto.Int32(1),
+						Label:  descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+						Type:   descriptor.FieldDescriptorProto_TYPE_STRING.Enum(),
+					},
+					{
+						Name:   proto.String("count"),
+						Number: proto.Int32(2),
+						Label:  descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+						Type:   descriptor.FieldDescriptorProto_TYPE_INT32.Enum(),
+					},
+					{
+						Name:   proto.String("names"),
+						Number: proto.Int32(3),
+						Label:  descriptor.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+						Type:   descriptor.FieldDescriptorProto_TYPE_STRING.Enum(),
+					},
+				},
+			},
+		},
+		SourceCodeInfo: &descriptor.SourceCodeInfo{
+			Location: []*descriptor.SourceCodeInfo_Location{
+				{Path: []int32{4, 0, 2, 0}, LeadingComments: proto.String(" The ID.\n\n Must be unique.\n")},
+				{Path: []int32{4, 0, 2, 2}, TrailingComments: proto.String(" The names.\n")},
+			},
+		},
+	}
+	f := &tmplFuncs{protoFileDescriptor: file, protoFiles: []*descriptor.FileDescriptorProto{file}}
+
+	expected := `message Foo {
+  // The ID.
+  //
+  // Must be unique.
+  string id = 1;
+  int32 count = 2;
+  // The names.
+  repeated string names = 3;
+}
+`
+	if got := f.definitionWithComments(file.MessageType[0]); got != expected {
+		t.Fatalf("got:\n%s\nexpected:\n%s", got, expected)
+	}
+}
+
+func TestFieldOptionsDefaults(t *testing.T) {
+	var testCases = []struct {
+		fieldType descriptor.FieldDescriptorProto_Type
+		value     string
+		expected  string
+	}{
+		{fieldType: descriptor.FieldDescriptorProto_TYPE_STRING, value: `say "hi"`, expected: ` [default = "say \"hi\""]`},
+		{fieldType: descriptor.FieldDescriptorProto_TYPE_BYTES, value: `\001ab`, expected: ` [default = "\001ab"]`},
+		{fieldType: descriptor.FieldDescriptorProto_TYPE_INT32, value: "-3", expected: ` [default = -3]`},
+	}
+	for _, testCase := range testCases {
+		field := &descriptor.FieldDescriptorProto{
+			Name:         proto.String("value"),
+			Type:         testCase.fieldType.Enum(),
+			DefaultValue: proto.String(testCase.value),
+		}
+		if got := fieldOptions(field); got != testCase.expected {
+			t.Errorf("%s: got %q expected %q", testCase.fieldType, got, testCase.expected)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package tmpl
+
+import (
+	"sort"
+
+	"github.com/dnephin/proto-gen-html/util"
+	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
+)
+
+// packageReference is the number of references from the types of one package
+// to the types of another package.
+type packageReference struct {
+	FromPackage string
+	ToPackage   string
+	Count       int
+}
+
+// crossPackageReferences returns the number of times the fields and methods of
+// the files being generated reference types in another package, for each pair
+// of packages. The references are sorted by FromPackage and then ToPackage.
+// References to types which can not be resolved are not counted.
+func crossPackageReferences(request *plugin.CodeGeneratorRequest) []packageReference {
+	resolver := util.NewResolver(request.GetProtoFile())
+	counts := make(map[[2]string]int)
+	count := func(from, typeName string) {
+		if typeName == "" {
+			return
+		}
+		_, file := resolver.Resolve(typeName, nil)
+		if file == nil || file.GetPackage() == from {
+			return
+		}
+		counts[[2]string{from, file.GetPackage()}]++
+	}
+
+	for _, name := range request.GetFileToGenerate() {
+		file := getProtoFileFromTarget(name, request)
+		pkg := file.GetPackage()
+		for _, msg := range util.AllMessages(file) {
+			for _, field := range msg.GetField() {
+				count(pkg, field.GetTypeName())
+			}
+		}
+		for _, service := range file.GetService() {
+			for _, method := range service.GetMethod() {
+				count(pkg, method.GetInputType())
+				count(pkg, method.GetOutputType())
+			}
+		}
+	}
+
+	var all []packageReference
+	for key, n := range counts {
+		all = append(all, packageReference{FromPackage: key[0], ToPackage: key[1], Count: n})
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].FromPackage != all[j].FromPackage {
+			return all[i].FromPackage < all[j].FromPackage
+		}
+		return all[i].ToPackage < all[j].ToPackage
+	})
+	return all
+}
@@ -0,0 +1,71 @@
+package tmpl
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
+)
+
+func messageField(name, typeName string) *descriptor.FieldDescriptorProto {
+	return &descriptor.FieldDescriptorProto{
+		Name:     proto.String(name),
+		Type:     descriptor.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+		TypeName: proto.String(typeName),
+	}
+}
+
+func TestCrossPackageReferences(t *testing.T) {
+	users := &descriptor.FileDescriptorProto{
+		Name:    proto.String("users.proto"),
+		Package: proto.String("users"),
+		MessageType: []*descriptor.DescriptorProto{
+			{
+				Name: proto.String("User"),
+				Field: []*descriptor.FieldDescriptorProto{
+					messageField("groups", ".groups.Group"),
+					messageField("primary", ".groups.Group"),
+					messageField("manager", ".users.User"),
+					messageField("missing", ".other.Missing"),
+				},
+			},
+		},
+		Service: []*descriptor.ServiceDescriptorProto{
+			{
+				Name: proto.String("Users"),
+				Method: []*descriptor.MethodDescriptorProto{
+					{
+						Name:       proto.String("ListMembers"),
+						InputType:  proto.String(".groups.Group"),
+						OutputType: proto.String(".users.User"),
+					},
+				},
+			},
+		},
+	}
+	groups := &descriptor.FileDescriptorProto{
+		Name:    proto.String("groups.proto"),
+		Package: proto.String("groups"),
+		MessageType: []*descriptor.DescriptorProto{
+			{
+				Name:  proto.String("Group"),
+				Field: []*descriptor.FieldDescriptorProto{messageField("owner", ".users.User")},
+			},
+		},
+	}
+	request := &plugin.CodeGeneratorRequest{
+		FileToGenerate: []string{"users.proto", "groups.proto"},
+		ProtoFile:      []*descriptor.FileDescriptorProto{users, groups},
+	}
+
+	got := crossPackageReferences(request)
+	expected := []packageReference{
+		{FromPackage: "groups", ToPackage: "users", Count: 1},
+		{FromPackage: "users", ToPackage: "groups", Count: 3},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %+v expected %+v", got, expected)
+	}
+}
@@ -0,0 +1,89 @@
+package tmpl
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// Field numbers of the reserved_range and reserved_name fields of
+// EnumDescriptorProto, and of the start and end fields of EnumReservedRange.
+// The descriptor package predates reserved enum values, so they are read from
+// the unrecognized fields of the descriptor.
+const (
+	enumReservedRangeFieldNumber = 4
+	enumReservedNameFieldNumber  = 5
+	enumReservedStartFieldNumber = 1
+	enumReservedEndFieldNumber   = 2
+)
+
+// reservedRanges returns the reserved numbers of a message or enum, one entry
+// per range, e.g. "2", or "9-11". Ranges which extend to the largest valid
+// number end with "max", e.g. "1000-max".
+func reservedRanges(x interface{}) []string {
+	var (
+		ranges []reservedRange
+		max    int32
+	)
+	switch v := x.(type) {
+	case *descriptor.DescriptorProto:
+		ranges, max = messageReservedRanges(v), maxFieldNumber
+	case *descriptor.EnumDescriptorProto:
+		ranges, max = enumReservedRanges(v), math.MaxInt32
+	}
+
+	var out []string
+	for _, r := range ranges {
+		last := r.End - 1
+		switch {
+		case last == r.Start:
+			out = append(out, fmt.Sprint(r.Start))
+		case last == max:
+			out = append(out, fmt.Sprintf("%d-max", r.Start))
+		default:
+			out = append(out, fmt.Sprintf("%d-%d", r.Start, last))
+		}
+	}
+	return out
+}
+
+// reservedNames returns the reserved names of a message or enum.
+func reservedNames(x interface{}) []string {
+	switch v := x.(type) {
+	case *descriptor.DescriptorProto:
+		return v.GetReservedName()
+	case *descriptor.EnumDescriptorProto:
+		return enumReservedNames(v)
+	}
+	return nil
+}
+
+// enumReservedRanges returns the reserved ranges of the enum. The end of the
+// ranges of an enum is inclusive, so it is converted to the exclusive End of a
+// reservedRange.
+func enumReservedRanges(enum *descriptor.EnumDescriptorProto) []reservedRange {
+	var ranges []reservedRange
+	unknownFields(enum.XXX_unrecognized, func(key, _ uint64, raw []byte) bool {
+		if key>>3 != enumReservedRangeFieldNumber || key&0x7 != proto.WireBytes {
+			return true
+		}
+		start, _ := unknownVarint(raw, enumReservedStartFieldNumber)
+		end, _ := unknownVarint(raw, enumReservedEndFieldNumber)
+		ranges = append(ranges, reservedRange{Start: int32(start), End: int32(end) + 1})
+		return true
+	})
+	return ranges
+}
+
+func enumReservedNames(enum *descriptor.EnumDescriptorProto) []string {
+	var names []string
+	unknownFields(enum.XXX_unrecognized, func(key, _ uint64, raw []byte) bool {
+		if key>>3 == enumReservedNameFieldNumber && key&0x7 == proto.WireBytes {
+			names = append(names, string(raw))
+		}
+		return true
+	})
+	return names
+}
@@ -0,0 +1,65 @@
+package tmpl
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestReservedMessage(t *testing.T) {
+	msg := &descriptor.DescriptorProto{
+		Name: proto.String("Thing"),
+		ReservedRange: []*descriptor.DescriptorProto_ReservedRange{
+			{Start: proto.Int32(2), End: proto.Int32(3)},
+			{Start: proto.Int32(9), End: proto.Int32(12)},
+			{Start: proto.Int32(1000), End: proto.Int32(maxFieldNumber + 1)},
+		},
+		ReservedName: []string{"foo", "bar"},
+	}
+
+	expected := []string{"2", "9-11", "1000-max"}
+	if got := reservedRanges(msg); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %v expected %v", got, expected)
+	}
+	if got := reservedNames(msg); !reflect.DeepEqual(got, []string{"foo", "bar"}) {
+		t.Fatalf("got %v expected [foo bar]", got)
+	}
+}
+
+// encodeEnumReserved returns the encoded reserved_range and reserved_name
+// fields of an EnumDescriptorProto.
+func encodeEnumReserved(ranges [][2]int64, names ...string) []byte {
+	buf := proto.NewBuffer(nil)
+	for _, r := range ranges {
+		inner := proto.NewBuffer(nil)
+		inner.EncodeVarint(enumReservedStartFieldNumber<<3 | proto.WireVarint)
+		inner.EncodeVarint(uint64(r[0]))
+		inner.EncodeVarint(enumReservedEndFieldNumber<<3 | proto.WireVarint)
+		inner.EncodeVarint(uint64(r[1]))
+		buf.EncodeVarint(enumReservedRangeFieldNumber<<3 | proto.WireBytes)
+		buf.EncodeStringBytes(string(inner.Bytes()))
+	}
+	for _, name := range names {
+		buf.EncodeVarint(enumReservedNameFieldNumber<<3 | proto.WireBytes)
+		buf.EncodeStringBytes(name)
+	}
+	return buf.Bytes()
+}
+
+func TestReservedEnum(t *testing.T) {
+	enum := &descriptor.EnumDescriptorProto{
+		Name:             proto.String("Kind"),
+		XXX_unrecognized: encodeEnumReserved([][2]int64{{2, 2}, {9, 11}, {-5, -1}, {100, math.MaxInt32}}, "OLD"),
+	}
+
+	expected := []string{"2", "9-11", "-5--1", "100-max"}
+	if got := reservedRanges(enum); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %v expected %v", got, expected)
+	}
+	if got := reservedNames(enum); !reflect.DeepEqual(got, []string{"OLD"}) {
+		t.Fatalf("got %v expected [OLD]", got)
+	}
+}
@@ -0,0 +1,109 @@
+package tmpl
+
+import (
+	"encoding/json"
+
+	"github.com/dnephin/proto-gen-html/util"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+const formatSearch = "search"
+
+// SearchEntry is a documented symbol in the search index written by operations
+// with the "search" Format. The index is a JSON array of entries, which can be
+// loaded by a client-side search library such as lunr.js.
+type SearchEntry struct {
+	// Name is the fully-qualified name of the symbol, without the leading
+	// ".", e.g. "foo.Outer.Inner", "foo.Things.Watch", or "foo.Thing.name".
+	Name string `json:"name"`
+	// Kind is one of "message", "field", "enum", "enumValue", "service", or
+	// "method".
+	Kind string `json:"kind"`
+	// File is the name of the proto file which declares the symbol.
+	File string `json:"file"`
+	// URL is the URL of the documentation of the symbol, or of the enclosing
+	// message or enum for fields and enum values.
+	URL string `json:"url"`
+	// Summary is the first paragraph of the comment of the symbol.
+	Summary string `json:"summary"`
+}
+
+// genSearchIndex returns the JSON encoded search index of the files being
+// generated. Symbols are listed file by file, in the same order and with the
+// same declarations excluded as by the template functions, e.g. allMessages
+// and fields.
+func (g *generator) genSearchIndex(opConfig OperationConfig) (string, error) {
+	funcs := &tmplFuncs{
+		// Links in the index point at the html pages of each file.
+		outputFile: trimExt(opConfig.Output) + ".html",
+		urlRoot:    g.urlRoot(opConfig),
+		protoFiles: g.request.GetProtoFile(),
+		files:      g.filesToGenerate(),
+		locations:  g.locations,
+		config:     g.config,
+	}
+	index := &searchIndex{funcs: funcs, entries: []SearchEntry{}}
+	for _, file := range funcs.files {
+		index.file = file
+		for _, msg := range funcs.filterMessages(file.GetMessageType()) {
+			index.addMessage(msg, util.FullName(file, msg.GetName()))
+		}
+		for _, enum := range funcs.filterEnums(file.GetEnumType()) {
+			index.addEnum(enum, util.FullName(file, enum.GetName()))
+		}
+		for _, service := range funcs.services(file) {
+			fullName := util.FullName(file, service.GetName())
+			index.add(service, fullName, "service", funcs.typeURL(fullName))
+			for _, method := range funcs.methods(service) {
+				index.add(method, fullName+"."+method.GetName(), "method", funcs.methodURL(service, method))
+			}
+		}
+	}
+
+	out, err := json.MarshalIndent(index.entries, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(out) + "\n", nil
+}
+
+type searchIndex struct {
+	funcs   *tmplFuncs
+	file    *descriptor.FileDescriptorProto
+	entries []SearchEntry
+}
+
+func (s *searchIndex) add(node interface{}, fullName, kind, url string) {
+	s.entries = append(s.entries, SearchEntry{
+		Name:    fullName[1:],
+		Kind:    kind,
+		File:    s.file.GetName(),
+		URL:     url,
+		Summary: commentSummary(s.funcs.fieldDoc(node)),
+	})
+}
+
+func (s *searchIndex) addMessage(msg *descriptor.DescriptorProto, fullName string) {
+	if msg.GetOptions().GetMapEntry() {
+		return
+	}
+	url := s.funcs.typeURL(fullName)
+	s.add(msg, fullName, "message", url)
+	for _, field := range s.funcs.fields(msg) {
+		s.add(field, fullName+"."+field.GetName(), "field", url)
+	}
+	for _, nested := range s.funcs.filterMessages(msg.GetNestedType()) {
+		s.addMessage(nested, fullName+"."+nested.GetName())
+	}
+	for _, enum := range s.funcs.filterEnums(msg.GetEnumType()) {
+		s.addEnum(enum, fullName+"."+enum.GetName())
+	}
+}
+
+func (s *searchIndex) addEnum(enum *descriptor.EnumDescriptorProto, fullName string) {
+	url := s.funcs.typeURL(fullName)
+	s.add(enum, fullName, "enum", url)
+	for _, value := range s.funcs.enumValues(enum) {
+		s.add(value, fullName+"."+value.GetName(), "enumValue", url)
+	}
+}
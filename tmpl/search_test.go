@@ -0,0 +1,79 @@
+package tmpl
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestGenerateSearchIndex(t *testing.T) {
+	file := &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo/foo.proto"),
+		Package: proto.String("foo"),
+		MessageType: []*descriptor.DescriptorProto{
+			{
+				Name: proto.String("Thing"),
+				Field: []*descriptor.FieldDescriptorProto{
+					{Name: proto.String("name"), Number: proto.Int32(1)},
+				},
+				NestedType: []*descriptor.DescriptorProto{{Name: proto.String("Part")}},
+			},
+		},
+		EnumType: []*descriptor.EnumDescriptorProto{
+			{
+				Name:  proto.String("Kind"),
+				Value: []*descriptor.EnumValueDescriptorProto{{Name: proto.String("SMALL")}},
+			},
+		},
+		Service: []*descriptor.ServiceDescriptorProto{
+			{
+				Name: proto.String("Things"),
+				Method: []*descriptor.MethodDescriptorProto{
+					{
+						Name:       proto.String("Get"),
+						InputType:  proto.String(".foo.Thing"),
+						OutputType: proto.String(".foo.Thing"),
+					},
+				},
+			},
+		},
+		SourceCodeInfo: &descriptor.SourceCodeInfo{
+			Location: []*descriptor.SourceCodeInfo_Location{
+				{Path: []int32{4, 0}, LeadingComments: proto.String(" Thing is a thing.\n It has parts.\n\n Details.\n")},
+				{Path: []int32{6, 0, 2, 0}, LeadingComments: proto.String(" Get returns a thing.\n")},
+			},
+		},
+	}
+	config := Config{
+		Operations: []OperationConfig{
+			{Format: "search", Output: "search.json"},
+		},
+	}
+	response, err := Generate(newTestRequest(file), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Error != nil {
+		t.Fatalf("unexpected error: %s", response.GetError())
+	}
+
+	var got []SearchEntry
+	if err := json.Unmarshal([]byte(response.File[0].GetContent()), &got); err != nil {
+		t.Fatal(err)
+	}
+	expected := []SearchEntry{
+		{Name: "foo.Thing", Kind: "message", File: "foo/foo.proto", URL: "foo/foo.html#Thing", Summary: "Thing is a thing. It has parts."},
+		{Name: "foo.Thing.name", Kind: "field", File: "foo/foo.proto", URL: "foo/foo.html#Thing"},
+		{Name: "foo.Thing.Part", Kind: "message", File: "foo/foo.proto", URL: "foo/foo.html#Thing.Part"},
+		{Name: "foo.Kind", Kind: "enum", File: "foo/foo.proto", URL: "foo/foo.html#Kind"},
+		{Name: "foo.Kind.SMALL", Kind: "enumValue", File: "foo/foo.proto", URL: "foo/foo.html#Kind"},
+		{Name: "foo.Things", Kind: "service", File: "foo/foo.proto", URL: "foo/foo.html#Things"},
+		{Name: "foo.Things.Get", Kind: "method", File: "foo/foo.proto", URL: "foo/foo.html#Things.Get", Summary: "Get returns a thing."},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %+v\nexpected %+v", got, expected)
+	}
+}
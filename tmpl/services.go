@@ -0,0 +1,208 @@
+package tmpl
+
+import (
+	"path"
+	"sort"
+
+	"github.com/dnephin/proto-gen-html/util"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
+)
+
+// methodEntry is a method along with the service which declares it.
+type methodEntry struct {
+	Service       string
+	ServiceAnchor string
+	Method        *descriptor.MethodDescriptorProto
+}
+
+// serviceNavEntry is a service and its methods, for rendering navigation.
+type serviceNavEntry struct {
+	Service *descriptor.ServiceDescriptorProto
+	Name    string
+	Anchor  string
+	Methods []methodEntry
+}
+
+// fileMethods returns all the methods of all services in the file, in the
+// order of services and methods.
+func (f *tmplFuncs) fileMethods(file *descriptor.FileDescriptorProto) []methodEntry {
+	var all []methodEntry
+	for _, service := range f.services(file) {
+		all = append(all, f.serviceMethods(service)...)
+	}
+	return all
+}
+
+// fileServiceNav returns an entry for each service in the file, see services.
+func (f *tmplFuncs) fileServiceNav(file *descriptor.FileDescriptorProto) []serviceNavEntry {
+	var all []serviceNavEntry
+	for _, service := range f.services(file) {
+		all = append(all, serviceNavEntry{
+			Service: service,
+			Name:    service.GetName(),
+			Anchor:  f.serviceAnchor(service),
+			Methods: f.serviceMethods(service),
+		})
+	}
+	return all
+}
+
+func (f *tmplFuncs) serviceMethods(service *descriptor.ServiceDescriptorProto) []methodEntry {
+	var all []methodEntry
+	for _, method := range f.methods(service) {
+		all = append(all, methodEntry{
+			Service:       service.GetName(),
+			ServiceAnchor: f.serviceAnchor(service),
+			Method:        method,
+		})
+	}
+	return all
+}
+
+// serviceAnchor returns the anchor of the service, which matches the fragment
+// of the URL returned by typeURL for the service.
+func (f *tmplFuncs) serviceAnchor(service *descriptor.ServiceDescriptorProto) string {
+	file := f.serviceFile(service)
+	return f.anchor(util.FullName(file, service.GetName()), file)
+}
+
+// methodAnchor returns the anchor of the method of the service, e.g.
+// "Service.Method", which matches the fragment of the URL returned by
+// methodURL for the method.
+func (f *tmplFuncs) methodAnchor(service *descriptor.ServiceDescriptorProto, method *descriptor.MethodDescriptorProto) string {
+	file := f.serviceFile(service)
+	return f.anchor(util.FullName(file, service.GetName()+"."+method.GetName()), file)
+}
+
+// methodURL returns a URL to the documentation of the method of the service.
+func (f *tmplFuncs) methodURL(service *descriptor.ServiceDescriptorProto, method *descriptor.MethodDescriptorProto) string {
+	file := f.serviceFile(service)
+	if file == nil {
+		return ""
+	}
+	if f.singleDocument && f.isGenerated(file) {
+		return "#" + f.methodAnchor(service, method)
+	}
+	p := path.Join(f.linkRoot(file), f.config.pageName(file.GetName())+path.Ext(f.outputFile))
+	return p + "#" + f.methodAnchor(service, method)
+}
+
+// methodService returns the service in file which declares method, or nil if
+// the method is not declared in file.
+func methodService(file *descriptor.FileDescriptorProto, method *descriptor.MethodDescriptorProto) *descriptor.ServiceDescriptorProto {
+	for _, service := range file.GetService() {
+		for _, m := range service.GetMethod() {
+			if m == method {
+				return service
+			}
+		}
+	}
+	return nil
+}
+
+// streamingFlags are the directions in which a method streams messages.
+type streamingFlags struct {
+	ClientStream bool
+	ServerStream bool
+}
+
+// methodStreaming returns the streaming flags of the method.
+func methodStreaming(method *descriptor.MethodDescriptorProto) streamingFlags {
+	return streamingFlags{
+		ClientStream: method.GetClientStreaming(),
+		ServerStream: method.GetServerStreaming(),
+	}
+}
+
+// methodKind returns a label for the kind of the method: "unary",
+// "client streaming", "server streaming", or "bidirectional streaming".
+func methodKind(method *descriptor.MethodDescriptorProto) string {
+	flags := methodStreaming(method)
+	switch {
+	case flags.ClientStream && flags.ServerStream:
+		return "bidirectional streaming"
+	case flags.ClientStream:
+		return "client streaming"
+	case flags.ServerStream:
+		return "server streaming"
+	default:
+		return "unary"
+	}
+}
+
+// streamingMethodEntry is a method which streams in at least one direction.
+type streamingMethodEntry struct {
+	// Service is the fully-qualified name of the service.
+	Service string
+	Method  *descriptor.MethodDescriptorProto
+	// Kind is the label returned by methodKind for the method.
+	Kind string
+	// URL is the URL of the documentation of the service.
+	URL string
+}
+
+// streamingMethods returns the methods of all the files being generated which
+// use client or server streaming, in the order of services and methods.
+func (f *tmplFuncs) streamingMethods(request *plugin.CodeGeneratorRequest) []streamingMethodEntry {
+	var all []streamingMethodEntry
+	for _, name := range request.GetFileToGenerate() {
+		file := getProtoFileFromTarget(name, request)
+		for _, service := range f.services(file) {
+			fullName := util.FullName(file, service.GetName())
+			for _, method := range f.methods(service) {
+				if !method.GetClientStreaming() && !method.GetServerStreaming() {
+					continue
+				}
+				all = append(all, streamingMethodEntry{
+					Service: fullName,
+					Method:  method,
+					Kind:    methodKind(method),
+					URL:     f.typeURL(fullName),
+				})
+			}
+		}
+	}
+	return all
+}
+
+// serviceIndexEntry is a service in the index of all services.
+type serviceIndexEntry struct {
+	Service *descriptor.ServiceDescriptorProto
+	// FullName is the fully-qualified name of the service.
+	FullName string
+	Package  string
+	// File is the name of the file which declares the service.
+	File        string
+	MethodCount int
+	// URL is the URL of the documentation of the service.
+	URL string
+}
+
+// allServices returns the services of all the files being generated, sorted by
+// package and then by name. Deprecated services are excluded when
+// Config.HideDeprecated is set.
+func (f *tmplFuncs) allServices(request *plugin.CodeGeneratorRequest) []serviceIndexEntry {
+	var all []serviceIndexEntry
+	for _, name := range request.GetFileToGenerate() {
+		file := getProtoFileFromTarget(name, request)
+		for _, service := range f.services(file) {
+			fullName := util.FullName(file, service.GetName())
+			all = append(all, serviceIndexEntry{
+				Service:     service,
+				FullName:    fullName,
+				Package:     file.GetPackage(),
+				File:        file.GetName(),
+				MethodCount: len(f.methods(service)),
+				URL:         f.typeURL(fullName),
+			})
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].Package != all[j].Package {
+			return all[i].Package < all[j].Package
+		}
+		return all[i].Service.GetName() < all[j].Service.GetName()
+	})
+	return all
+}
@@ -0,0 +1,194 @@
+package tmpl
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
+)
+
+func newServicesFile() *descriptor.FileDescriptorProto {
+	return &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+		Service: []*descriptor.ServiceDescriptorProto{
+			{
+				Name: proto.String("First"),
+				Method: []*descriptor.MethodDescriptorProto{
+					{Name: proto.String("One")},
+				},
+			},
+			{
+				Name: proto.String("Second"),
+				Method: []*descriptor.MethodDescriptorProto{
+					{Name: proto.String("Two")},
+					{Name: proto.String("Three")},
+				},
+			},
+		},
+	}
+}
+
+func TestMethodService(t *testing.T) {
+	file := newServicesFile()
+	method := file.Service[1].Method[1]
+
+	got := methodService(file, method)
+	if got != file.Service[1] {
+		t.Fatalf("got %v expected service Second", got)
+	}
+
+	other := &descriptor.MethodDescriptorProto{Name: proto.String("Three")}
+	if got := methodService(file, other); got != nil {
+		t.Fatalf("expected nil for an unknown method, got %v", got)
+	}
+}
+
+func TestMethodListCarriesService(t *testing.T) {
+	file := newServicesFile()
+	f := &tmplFuncs{protoFiles: []*descriptor.FileDescriptorProto{file}}
+	methods := f.fileMethods(file)
+	if len(methods) != 3 {
+		t.Fatalf("expected 3 methods, got %d", len(methods))
+	}
+	last := methods[2]
+	if last.Service != "Second" || last.ServiceAnchor != "Second" || last.Method.GetName() != "Three" {
+		t.Fatalf("unexpected entry %+v", last)
+	}
+}
+
+func TestStreamingFlags(t *testing.T) {
+	var tests = []struct {
+		client, server bool
+		kind           string
+	}{
+		{false, false, "unary"},
+		{true, false, "client streaming"},
+		{false, true, "server streaming"},
+		{true, true, "bidirectional streaming"},
+	}
+	for _, tst := range tests {
+		method := &descriptor.MethodDescriptorProto{
+			Name:            proto.String("Do"),
+			ClientStreaming: proto.Bool(tst.client),
+			ServerStreaming: proto.Bool(tst.server),
+		}
+		got := methodStreaming(method)
+		expected := streamingFlags{ClientStream: tst.client, ServerStream: tst.server}
+		if got != expected {
+			t.Fatalf("got %+v expected %+v", got, expected)
+		}
+		if kind := methodKind(method); kind != tst.kind {
+			t.Fatalf("got %q expected %q", kind, tst.kind)
+		}
+	}
+}
+
+func TestStreamingMethods(t *testing.T) {
+	file := newServicesFile()
+	file.Service[0].Method[0].ServerStreaming = proto.Bool(true)
+	file.Service[1].Method[1].ClientStreaming = proto.Bool(true)
+	file.Service[1].Method[1].ServerStreaming = proto.Bool(true)
+	request := &plugin.CodeGeneratorRequest{
+		FileToGenerate: []string{"foo.proto"},
+		ProtoFile:      []*descriptor.FileDescriptorProto{file},
+	}
+	f := &tmplFuncs{outputFile: "foo.html", protoFiles: request.ProtoFile}
+
+	var got []string
+	for _, entry := range f.streamingMethods(request) {
+		got = append(got, entry.Service+"."+entry.Method.GetName()+" "+entry.Kind+" "+entry.URL)
+	}
+	expected := []string{
+		".foo.First.One server streaming foo.html#First",
+		".foo.Second.Three bidirectional streaming foo.html#Second",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %v expected %v", got, expected)
+	}
+}
+
+func TestAllServices(t *testing.T) {
+	foo := newServicesFile()
+	bar := &descriptor.FileDescriptorProto{
+		Name:    proto.String("bar.proto"),
+		Package: proto.String("bar"),
+		Service: []*descriptor.ServiceDescriptorProto{
+			{Name: proto.String("Zebra")},
+			{
+				Name:   proto.String("Alpha"),
+				Method: []*descriptor.MethodDescriptorProto{{Name: proto.String("Run")}},
+			},
+		},
+	}
+	request := &plugin.CodeGeneratorRequest{
+		FileToGenerate: []string{"foo.proto", "bar.proto"},
+		ProtoFile:      []*descriptor.FileDescriptorProto{foo, bar},
+	}
+	f := &tmplFuncs{outputFile: "index.html", protoFiles: request.ProtoFile}
+
+	var got []string
+	for _, entry := range f.allServices(request) {
+		got = append(got, fmt.Sprintf("%s %s %d %s", entry.FullName, entry.File, entry.MethodCount, entry.URL))
+	}
+	expected := []string{
+		".bar.Alpha bar.proto 1 bar.html#Alpha",
+		".bar.Zebra bar.proto 0 bar.html#Zebra",
+		".foo.First foo.proto 1 foo.html#First",
+		".foo.Second foo.proto 2 foo.html#Second",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %v expected %v", got, expected)
+	}
+}
+
+func TestServiceAnchorWithSlugAnchors(t *testing.T) {
+	file := newServicesFile()
+	file.MessageType = []*descriptor.DescriptorProto{{Name: proto.String("second")}}
+	f := &tmplFuncs{
+		outputFile: "foo.html",
+		protoFiles: []*descriptor.FileDescriptorProto{file},
+		files:      []*descriptor.FileDescriptorProto{file},
+		config:     Config{SlugAnchors: true},
+	}
+
+	nav := f.serviceNav(file)
+	if got, expected := nav[1].Anchor, "second-1"; got != expected {
+		t.Fatalf("got %q expected %q", got, expected)
+	}
+	if url := f.typeURL(".foo.Second"); url != "foo.html#"+nav[1].Anchor {
+		t.Fatalf("expected %q to link to anchor %q", url, nav[1].Anchor)
+	}
+	methods := f.methodList(file)
+	if got := methods[2].ServiceAnchor; got != nav[1].Anchor {
+		t.Fatalf("got %q expected %q", got, nav[1].Anchor)
+	}
+}
+
+func TestMethodAnchorMatchesMethodURL(t *testing.T) {
+	file := newServicesFile()
+	service, method := file.Service[1], file.Service[1].Method[1]
+
+	for _, single := range []bool{false, true} {
+		f := &tmplFuncs{
+			outputFile:     "foo.html",
+			protoFiles:     []*descriptor.FileDescriptorProto{file},
+			files:          []*descriptor.FileDescriptorProto{file},
+			singleDocument: single,
+		}
+		anchor := f.methodAnchor(service, method)
+		url := f.methodURL(service, method)
+		if !strings.HasSuffix(url, "#"+anchor) {
+			t.Fatalf("single=%v: expected %q to link to anchor %q", single, url, anchor)
+		}
+	}
+
+	f := &tmplFuncs{outputFile: "foo.html", protoFiles: []*descriptor.FileDescriptorProto{file}}
+	if got, expected := f.methodURL(service, method), "foo.html#Second.Three"; got != expected {
+		t.Fatalf("got %q expected %q", got, expected)
+	}
+}
@@ -0,0 +1,36 @@
+package tmpl
+
+import (
+	"regexp"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// sinceTagPattern matches a "@since <version>" tag in a comment.
+var sinceTagPattern = regexp.MustCompile(`(?m)^\s*@since\s+(\S+)`)
+
+// since returns the version the element was added in. The version is read
+// from a "@since <version>" tag in the leading comments of the element, or
+// else from the string option configured by Config.SinceOption. An empty string
+// is returned if neither is set.
+func (f *tmplFuncs) since(x interface{}) string {
+	if match := sinceTagPattern.FindStringSubmatch(f.location(x).GetLeadingComments()); match != nil {
+		return match[1]
+	}
+
+	if f.config.SinceOption == 0 {
+		return ""
+	}
+	options := nodeOptions(x)
+	if options == nil {
+		return ""
+	}
+	// The option is not registered in this process, so it is read from the
+	// encoded options.
+	data, err := proto.Marshal(options)
+	if err != nil {
+		return ""
+	}
+	value, _ := unknownBytes(data, uint64(f.config.SinceOption))
+	return string(value)
+}
@@ -0,0 +1,51 @@
+package tmpl
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+const testSinceOption = 50002
+
+func newSinceFile() *descriptor.FileDescriptorProto {
+	options := &descriptor.MessageOptions{}
+	value := append(proto.EncodeVarint(testSinceOption<<3|proto.WireBytes), proto.EncodeVarint(3)...)
+	value = append(value, "2.0"...)
+	proto.SetRawExtension(options, testSinceOption, value)
+
+	return &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+		MessageType: []*descriptor.DescriptorProto{
+			{Name: proto.String("Tagged")},
+			{Name: proto.String("WithOption"), Options: options},
+			{Name: proto.String("Unversioned")},
+		},
+		SourceCodeInfo: &descriptor.SourceCodeInfo{
+			Location: []*descriptor.SourceCodeInfo_Location{
+				{Path: []int32{4, 0}, LeadingComments: proto.String(" A message.\n\n @since 1.3\n")},
+			},
+		},
+	}
+}
+
+func TestSince(t *testing.T) {
+	f := newTestFuncs(Config{SinceOption: testSinceOption}, newSinceFile())
+	messages := f.protoFileDescriptor.MessageType
+
+	var testCases = []struct {
+		msg      *descriptor.DescriptorProto
+		expected string
+	}{
+		{msg: messages[0], expected: "1.3"},
+		{msg: messages[1], expected: "2.0"},
+		{msg: messages[2], expected: ""},
+	}
+	for _, testCase := range testCases {
+		if got := f.since(testCase.msg); got != testCase.expected {
+			t.Errorf("%s: got %q expected %q", testCase.msg.GetName(), got, testCase.expected)
+		}
+	}
+}
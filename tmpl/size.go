@@ -0,0 +1,80 @@
+package tmpl
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// sizeEstimate is a rough estimate, in bytes, of the encoded size of a
+// message.
+type sizeEstimate struct {
+	// Min is the size when every singular field is set to its smallest value
+	// and repeated fields are empty.
+	Min int
+	// Typical is the size when every field is set to a typical value, and
+	// repeated fields have a few elements.
+	Typical int
+}
+
+// Heuristics for the typical size of variable length values.
+const (
+	typicalVarint32    = 3
+	typicalVarint64    = 5
+	typicalStringBytes = 16
+	typicalBytesBytes  = 32
+	typicalMessage     = 16
+	typicalRepeated    = 4
+)
+
+// estimatedSize returns a heuristic estimate of the binary encoded size of the
+// message based on the types of its fields. Fixed width types have an exact
+// size, while the sizes of varints, strings, bytes, and nested messages are
+// estimates. Nested messages are not inspected.
+func estimatedSize(msg *descriptor.DescriptorProto) sizeEstimate {
+	var estimate sizeEstimate
+	for _, field := range msg.GetField() {
+		tag := len(proto.EncodeVarint(uint64(field.GetNumber()) << 3))
+		min, typical := valueSize(field)
+		if field.GetLabel() == descriptor.FieldDescriptorProto_LABEL_REPEATED {
+			estimate.Typical += typicalRepeated * (tag + typical)
+			continue
+		}
+		estimate.Min += tag + min
+		estimate.Typical += tag + typical
+	}
+	return estimate
+}
+
+// valueSize returns the min and typical size of a single value of the field,
+// including the length prefix of length delimited values.
+func valueSize(field *descriptor.FieldDescriptorProto) (int, int) {
+	switch field.GetType() {
+	case descriptor.FieldDescriptorProto_TYPE_FIXED32,
+		descriptor.FieldDescriptorProto_TYPE_SFIXED32,
+		descriptor.FieldDescriptorProto_TYPE_FLOAT:
+		return 4, 4
+	case descriptor.FieldDescriptorProto_TYPE_FIXED64,
+		descriptor.FieldDescriptorProto_TYPE_SFIXED64,
+		descriptor.FieldDescriptorProto_TYPE_DOUBLE:
+		return 8, 8
+	case descriptor.FieldDescriptorProto_TYPE_BOOL:
+		return 1, 1
+	case descriptor.FieldDescriptorProto_TYPE_ENUM:
+		return 1, 1
+	case descriptor.FieldDescriptorProto_TYPE_INT32,
+		descriptor.FieldDescriptorProto_TYPE_UINT32,
+		descriptor.FieldDescriptorProto_TYPE_SINT32:
+		return 1, typicalVarint32
+	case descriptor.FieldDescriptorProto_TYPE_INT64,
+		descriptor.FieldDescriptorProto_TYPE_UINT64,
+		descriptor.FieldDescriptorProto_TYPE_SINT64:
+		return 1, typicalVarint64
+	case descriptor.FieldDescriptorProto_TYPE_STRING:
+		return 1, 1 + typicalStringBytes
+	case descriptor.FieldDescriptorProto_TYPE_BYTES:
+		return 1, 1 + typicalBytesBytes
+	default:
+		// Messages and groups.
+		return 1, 1 + typicalMessage
+	}
+}
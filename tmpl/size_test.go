@@ -0,0 +1,60 @@
+package tmpl
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestEstimatedSizeFixedFields(t *testing.T) {
+	msg := &descriptor.DescriptorProto{
+		Name: proto.String("Point"),
+		Field: []*descriptor.FieldDescriptorProto{
+			{
+				Name:   proto.String("x"),
+				Number: proto.Int32(1),
+				Label:  descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:   descriptor.FieldDescriptorProto_TYPE_FIXED32.Enum(),
+			},
+			{
+				Name:   proto.String("y"),
+				Number: proto.Int32(20),
+				Label:  descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:   descriptor.FieldDescriptorProto_TYPE_DOUBLE.Enum(),
+			},
+		},
+	}
+	// 1 byte tag + 4 bytes, and 2 byte tag + 8 bytes.
+	expected := sizeEstimate{Min: 15, Typical: 15}
+	if got := estimatedSize(msg); got != expected {
+		t.Fatalf("got %+v expected %+v", got, expected)
+	}
+}
+
+func TestEstimatedSizeVariableFields(t *testing.T) {
+	msg := &descriptor.DescriptorProto{
+		Name: proto.String("Item"),
+		Field: []*descriptor.FieldDescriptorProto{
+			{
+				Name:   proto.String("id"),
+				Number: proto.Int32(1),
+				Label:  descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:   descriptor.FieldDescriptorProto_TYPE_FIXED64.Enum(),
+			},
+			{
+				Name:   proto.String("tags"),
+				Number: proto.Int32(2),
+				Label:  descriptor.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+				Type:   descriptor.FieldDescriptorProto_TYPE_STRING.Enum(),
+			},
+		},
+	}
+	got := estimatedSize(msg)
+	if got.Min != 9 {
+		t.Fatalf("got min %d expected the size of the fixed field, 9", got.Min)
+	}
+	if got.Typical <= got.Min {
+		t.Fatalf("expected typical %d to be larger than min %d", got.Typical, got.Min)
+	}
+}
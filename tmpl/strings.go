@@ -0,0 +1,20 @@
+package tmpl
+
+import (
+	"strings"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// title returns s with the first letter of each word in upper case, and the
+// other letters in lower case.
+func title(s string) string {
+	// A Caser keeps state, so a new one is used for each call.
+	return cases.Title(language.Und).String(s)
+}
+
+// replace returns s with every instance of old replaced by new.
+func replace(s, old, new string) string {
+	return strings.Replace(s, old, new, -1)
+}
@@ -0,0 +1,29 @@
+package tmpl
+
+import (
+	"bytes"
+	"html/template"
+	"testing"
+)
+
+func TestStringFuncs(t *testing.T) {
+	var tests = map[string]string{
+		`{{lower "Outer.Inner"}}`:                     "outer.inner",
+		`{{upper "foo"}}`:                             "FOO",
+		`{{title "api v1 things"}}`:                   "Api V1 Things",
+		`{{title "hELLO"}}`:                           "Hello",
+		`{{replace "foo.bar.Baz" "." "/"}}`:           "foo/bar/Baz",
+		`{{trimPrefix "foo.Bar" "foo."}}`:             "Bar",
+		`{{if hasPrefix "foo.Bar" "foo."}}yes{{end}}`: "yes",
+	}
+	for source, expected := range tests {
+		tmpl := template.Must(template.New("strings").Funcs(newDefaultTemplateFuncs()).Parse(source))
+		buf := new(bytes.Buffer)
+		if err := tmpl.Execute(buf, nil); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != expected {
+			t.Errorf("%s: got %q expected %q", source, got, expected)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package tmpl
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// fieldTable returns an html table of the fields of the message returned by
+// fields, with a row rendered by fieldRow for each field.
+func (f *tmplFuncs) fieldTable(msg *descriptor.DescriptorProto) template.HTML {
+	buf := new(bytes.Buffer)
+	buf.WriteString(`<table class="fields">`)
+	buf.WriteString(`<tr><th>#</th><th>Field</th><th>Label</th><th>Type</th><th>Description</th></tr>`)
+	for _, field := range f.fields(msg) {
+		buf.WriteString(string(f.fieldRow(msg, field)))
+	}
+	buf.WriteString(`</table>`)
+	return template.HTML(buf.String())
+}
+
+// fieldRow returns an html table row for the field of the message, with the
+// number, name, label, type and description of the field. Descriptions longer
+// than Config.DescriptionMaxChars are truncated, followed by a "more" link to
+// the full description, which is in the same cell.
+func (f *tmplFuncs) fieldRow(msg *descriptor.DescriptorProto, field *descriptor.FieldDescriptorProto) template.HTML {
+	anchor := f.declAnchor(f.fieldFile(field), msg.GetName()) + "." + field.GetName()
+	return template.HTML(fmt.Sprintf(
+		`<tr id="%s"><td>%d</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>`,
+		template.HTMLEscapeString(anchor),
+		field.GetNumber(),
+		template.HTMLEscapeString(f.fieldName(field)),
+		f.syntaxLabel(field),
+		f.fieldTypeLink(field),
+		f.fieldDescription(anchor, strings.TrimSpace(f.location(field).GetLeadingComments()))))
+}
+
+// fieldDescription returns the html of the description cell of a field row.
+func (f *tmplFuncs) fieldDescription(anchor, description string) string {
+	max := f.config.DescriptionMaxChars
+	runes := []rune(description)
+	if max <= 0 || len(runes) <= max {
+		return template.HTMLEscapeString(description)
+	}
+
+	id := template.HTMLEscapeString(anchor + "-description")
+	return fmt.Sprintf(
+		`<span class="description-short">%s…</span> <a class="more" href="#%s">more</a>`+
+			`<div class="description-full" id="%s">%s</div>`,
+		template.HTMLEscapeString(strings.TrimSpace(string(runes[:max]))), id,
+		id, template.HTMLEscapeString(description))
+}
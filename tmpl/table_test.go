@@ -0,0 +1,91 @@
+package tmpl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func newTableFile() *descriptor.FileDescriptorProto {
+	return &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+		MessageType: []*descriptor.DescriptorProto{
+			{
+				Name: proto.String("Foo"),
+				Field: []*descriptor.FieldDescriptorProto{
+					{
+						Name:   proto.String("count"),
+						Number: proto.Int32(1),
+						Label:  descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+						Type:   descriptor.FieldDescriptorProto_TYPE_INT32.Enum(),
+					},
+				},
+			},
+		},
+		SourceCodeInfo: &descriptor.SourceCodeInfo{
+			Location: []*descriptor.SourceCodeInfo_Location{
+				{
+					Path:            []int32{4, 0, 2, 0},
+					LeadingComments: proto.String(" The number of things in the list.\n"),
+				},
+			},
+		},
+	}
+}
+
+func TestFieldRow(t *testing.T) {
+	f := newTestFuncs(Config{}, newTableFile())
+	msg := f.protoFileDescriptor.MessageType[0]
+
+	got := string(f.fieldRow(msg, msg.Field[0]))
+	expected := `<tr id="Foo.count"><td>1</td><td>count</td><td>optional</td><td>int32</td>` +
+		`<td>The number of things in the list.</td></tr>`
+	if got != expected {
+		t.Fatalf("got %q expected %q", got, expected)
+	}
+}
+
+func TestFieldTableTruncatesDescription(t *testing.T) {
+	f := newTestFuncs(Config{DescriptionMaxChars: 10}, newTableFile())
+	msg := f.protoFileDescriptor.MessageType[0]
+
+	got := string(f.fieldTable(msg))
+	expected := `<td><span class="description-short">The number…</span> ` +
+		`<a class="more" href="#Foo.count-description">more</a>` +
+		`<div class="description-full" id="Foo.count-description">The number of things in the list.</div></td>`
+	if !strings.Contains(got, expected) {
+		t.Fatalf("expected %q to contain %q", got, expected)
+	}
+}
+
+func TestFieldTableUsesFields(t *testing.T) {
+	f := newTestFuncs(visibilityConfig("PUBLIC"), newVisibilityFile())
+	f.config.Canonical = true
+	msg := &descriptor.DescriptorProto{
+		Name: proto.String("Foo"),
+		Field: []*descriptor.FieldDescriptorProto{
+			visibilityField("second", 0),
+			visibilityField("secret", 2),
+			visibilityField("first", 0),
+		},
+	}
+	for i, number := range []int32{2, 3, 1} {
+		msg.Field[i].Number = proto.Int32(number)
+		msg.Field[i].Type = descriptor.FieldDescriptorProto_TYPE_STRING.Enum()
+	}
+	file := f.protoFiles[0]
+	file.MessageType = []*descriptor.DescriptorProto{msg}
+	f.protoFileDescriptor = file
+
+	got := string(f.fieldTable(msg))
+	if strings.Contains(got, "secret") {
+		t.Fatalf("expected %q to exclude the private field", got)
+	}
+	first, second := strings.Index(got, `id="Foo.first"`), strings.Index(got, `id="Foo.second"`)
+	if first < 0 || second < 0 || first > second {
+		t.Fatalf("expected %q to list first before second", got)
+	}
+}
@@ -0,0 +1,99 @@
+package tmpl
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// isTargetPattern returns true if the target of an operation is a glob pattern
+// instead of the name of a proto file.
+func isTargetPattern(target string) bool {
+	return strings.ContainsAny(target, "*?[")
+}
+
+// outputContext is the data used to execute the Output of an operation with a
+// Target pattern.
+type outputContext struct {
+	// Name is the name of the matched proto file without its extension, e.g.
+	// "api/v1/things" for "api/v1/things.proto". It is slugged when
+	// Config.SlugPageNames is set, so that it matches the links to the page.
+	Name string
+}
+
+// expandTarget returns the operations for each proto file matched by the Target
+// pattern of the operation, with their Output executed as a template. An
+// operation with a Target which is not a pattern is returned unchanged.
+func (g *generator) expandTarget(opConfig OperationConfig) ([]OperationConfig, error) {
+	if !isTargetPattern(opConfig.Target) {
+		return []OperationConfig{opConfig}, nil
+	}
+	pattern, err := globPattern(opConfig.Target)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid generator target pattern %q", opConfig.Target)
+	}
+	output, err := template.New("output").Parse(opConfig.Output)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid output %q", opConfig.Output)
+	}
+
+	var ops []OperationConfig
+	for _, file := range g.request.GetProtoFile() {
+		if !pattern.MatchString(file.GetName()) {
+			continue
+		}
+		buf := new(bytes.Buffer)
+		ctx := outputContext{Name: g.config.pageName(file.GetName())}
+		if err := output.Execute(buf, ctx); err != nil {
+			return nil, errors.Wrapf(err, "failed to render output %q", opConfig.Output)
+		}
+		op := opConfig
+		op.Target = file.GetName()
+		op.Output = buf.String()
+		ops = append(ops, op)
+	}
+	if len(ops) == 0 {
+		return nil, errors.Errorf("no input proto files match generator target pattern %q", opConfig.Target)
+	}
+	return ops, nil
+}
+
+// globPattern returns a regular expression which matches the same names as the
+// glob. The glob uses the syntax of path.Match, and "**" matches any number of
+// directories, e.g. "api/**/*.proto" matches "api/a.proto" and "api/v1/a.proto".
+func globPattern(glob string) (*regexp.Regexp, error) {
+	buf := new(bytes.Buffer)
+	buf.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case strings.HasPrefix(glob[i:], "**/"):
+			buf.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			buf.WriteString(".*")
+			i++
+		case c == '*':
+			buf.WriteString("[^/]*")
+		case c == '?':
+			buf.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i:], ']')
+			if end < 0 {
+				return nil, errors.New("unterminated character class")
+			}
+			class := glob[i+1 : i+end]
+			if strings.HasPrefix(class, "^") {
+				class = "^/" + class[1:]
+			}
+			buf.WriteString("[" + class + "]")
+			i += end
+		default:
+			buf.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	buf.WriteString("$")
+	return regexp.Compile(buf.String())
+}
@@ -0,0 +1,68 @@
+package tmpl
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
+)
+
+func TestGlobPattern(t *testing.T) {
+	var testCases = []struct {
+		glob    string
+		name    string
+		matches bool
+	}{
+		{glob: "api/**/*.proto", name: "api/a.proto", matches: true},
+		{glob: "api/**/*.proto", name: "api/v1/beta/a.proto", matches: true},
+		{glob: "api/**/*.proto", name: "other/a.proto"},
+		{glob: "api/*.proto", name: "api/v1/a.proto"},
+		{glob: "api/?.proto", name: "api/a.proto", matches: true},
+		{glob: "api/[ab].proto", name: "api/c.proto"},
+		{glob: "api/[^c].proto", name: "api/a.proto", matches: true},
+		{glob: "a.b/*.proto", name: "aXb/a.proto"},
+	}
+	for _, testCase := range testCases {
+		pattern, err := globPattern(testCase.glob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := pattern.MatchString(testCase.name); got != testCase.matches {
+			t.Errorf("%q matching %q: got %v", testCase.glob, testCase.name, got)
+		}
+	}
+}
+
+func TestExpandTarget(t *testing.T) {
+	g := &generator{request: &plugin.CodeGeneratorRequest{
+		ProtoFile: []*descriptor.FileDescriptorProto{
+			{Name: proto.String("api/v1/things.proto")},
+			{Name: proto.String("api/v1/users.proto")},
+			{Name: proto.String("other.proto")},
+		},
+	}}
+
+	got, err := g.expandTarget(OperationConfig{
+		Template: "tmpl.html",
+		Target:   "api/**/*.proto",
+		Output:   "docs/{{.Name}}.html",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []OperationConfig{
+		{Template: "tmpl.html", Target: "api/v1/things.proto", Output: "docs/api/v1/things.html"},
+		{Template: "tmpl.html", Target: "api/v1/users.proto", Output: "docs/api/v1/users.html"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %+v expected %+v", got, expected)
+	}
+
+	_, err = g.expandTarget(OperationConfig{Target: "missing/*.proto"})
+	expectedErr := `no input proto files match generator target pattern "missing/*.proto"`
+	if err == nil || err.Error() != expectedErr {
+		t.Fatalf("expected error %q, got %v", expectedErr, err)
+	}
+}
@@ -0,0 +1,69 @@
+package tmpl
+
+import "github.com/golang/protobuf/protoc-gen-go/descriptor"
+
+// tocEntry is an entry of the table of contents of a file.
+type tocEntry struct {
+	// Label is the name of the declaration, which is parent-qualified for
+	// nested types, e.g. "Outer.Inner".
+	Label string
+	// Anchor is the anchor of the declaration, which matches the fragment of
+	// the URL returned by typeURL.
+	Anchor string
+	// Kind is "message", "enum", or "service".
+	Kind string
+	// Depth is 0 for top-level declarations, and one more than the depth of
+	// the enclosing message for nested types.
+	Depth int
+}
+
+// tableOfContents returns an entry for each message, enum, and service of the
+// file. Messages are listed first, each followed by its nested types, then
+// enums, and then services, each in declaration order, or sorted by name when
+// Config.Canonical is set. The synthetic entry messages of map fields are
+// excluded, as are deprecated declarations when Config.HideDeprecated is set.
+func (f *tmplFuncs) tableOfContents(file *descriptor.FileDescriptorProto) []tocEntry {
+	toc := &tocBuilder{funcs: f, file: file}
+	for _, msg := range f.filterMessages(file.GetMessageType()) {
+		toc.addMessage(msg, "", 0)
+	}
+	for _, enum := range f.filterEnums(file.GetEnumType()) {
+		toc.add(enum.GetName(), "enum", 0)
+	}
+	for _, service := range f.services(file) {
+		toc.add(service.GetName(), "service", 0)
+	}
+	return toc.entries
+}
+
+type tocBuilder struct {
+	funcs   *tmplFuncs
+	file    *descriptor.FileDescriptorProto
+	entries []tocEntry
+}
+
+func (b *tocBuilder) add(name, kind string, depth int) {
+	b.entries = append(b.entries, tocEntry{
+		Label:  name,
+		Anchor: b.funcs.declAnchor(b.file, name),
+		Kind:   kind,
+		Depth:  depth,
+	})
+}
+
+func (b *tocBuilder) addMessage(msg *descriptor.DescriptorProto, parent string, depth int) {
+	if msg.GetOptions().GetMapEntry() {
+		return
+	}
+	name := msg.GetName()
+	if parent != "" {
+		name = parent + "." + name
+	}
+	b.add(name, "message", depth)
+	for _, nested := range b.funcs.filterMessages(msg.GetNestedType()) {
+		b.addMessage(nested, name, depth+1)
+	}
+	for _, enum := range b.funcs.filterEnums(msg.GetEnumType()) {
+		b.add(name+"."+enum.GetName(), "enum", depth+1)
+	}
+}
@@ -0,0 +1,95 @@
+package tmpl
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestTableOfContents(t *testing.T) {
+	file := &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+		MessageType: []*descriptor.DescriptorProto{
+			{
+				Name: proto.String("Outer"),
+				NestedType: []*descriptor.DescriptorProto{
+					{
+						Name:       proto.String("Inner"),
+						NestedType: []*descriptor.DescriptorProto{{Name: proto.String("Deep")}},
+					},
+					{
+						Name:    proto.String("LabelsEntry"),
+						Options: &descriptor.MessageOptions{MapEntry: proto.Bool(true)},
+					},
+				},
+				EnumType: []*descriptor.EnumDescriptorProto{{Name: proto.String("Kind")}},
+			},
+			{Name: proto.String("Other")},
+		},
+		EnumType: []*descriptor.EnumDescriptorProto{{Name: proto.String("Color")}},
+		Service:  []*descriptor.ServiceDescriptorProto{{Name: proto.String("Things")}},
+	}
+	f := &tmplFuncs{
+		protoFileDescriptor: file,
+		outputFile:          "foo.html",
+		protoFiles:          []*descriptor.FileDescriptorProto{file},
+	}
+
+	expected := []tocEntry{
+		{Label: "Outer", Anchor: "Outer", Kind: "message", Depth: 0},
+		{Label: "Outer.Inner", Anchor: "Outer.Inner", Kind: "message", Depth: 1},
+		{Label: "Outer.Inner.Deep", Anchor: "Outer.Inner.Deep", Kind: "message", Depth: 2},
+		{Label: "Outer.Kind", Anchor: "Outer.Kind", Kind: "enum", Depth: 1},
+		{Label: "Other", Anchor: "Other", Kind: "message", Depth: 0},
+		{Label: "Color", Anchor: "Color", Kind: "enum", Depth: 0},
+		{Label: "Things", Anchor: "Things", Kind: "service", Depth: 0},
+	}
+	if got := f.tableOfContents(file); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %+v expected %+v", got, expected)
+	}
+
+	for _, entry := range expected[:6] {
+		url := f.typeURL(".foo." + entry.Label)
+		if expected := "foo.html#" + entry.Anchor; url != expected {
+			t.Fatalf("expected typeURL %q to match the anchor, got %q", expected, url)
+		}
+	}
+}
+
+func TestTableOfContentsCanonicalHideDeprecated(t *testing.T) {
+	file := &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+		MessageType: []*descriptor.DescriptorProto{
+			{
+				Name: proto.String("Outer"),
+				NestedType: []*descriptor.DescriptorProto{
+					{Name: proto.String("Zeta")},
+					{Name: proto.String("Alpha")},
+				},
+			},
+			{
+				Name:    proto.String("Old"),
+				Options: &descriptor.MessageOptions{Deprecated: proto.Bool(true)},
+			},
+		},
+	}
+	f := &tmplFuncs{
+		protoFileDescriptor: file,
+		outputFile:          "foo.html",
+		protoFiles:          []*descriptor.FileDescriptorProto{file},
+		config:              Config{Canonical: true, HideDeprecated: true},
+	}
+
+	var got []string
+	for _, entry := range f.tableOfContents(file) {
+		got = append(got, entry.Label)
+	}
+	expected := []string{"Outer", "Outer.Alpha", "Outer.Zeta"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %v expected %v", got, expected)
+	}
+}
@@ -0,0 +1,341 @@
+package tmpl
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/dnephin/proto-gen-html/util"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
+)
+
+// parentTypeEntry is the message which declares a nested type.
+type parentTypeEntry struct {
+	// FullName is the fully-qualified symbol path of the message.
+	FullName string
+	Message  *descriptor.DescriptorProto
+}
+
+// parentType returns the message which immediately encloses the type with the
+// symbolPath. A symbolPath which is not fully-qualified is resolved relative to
+// the scope, the same way as by typeURL. The zero value is returned for
+// top-level types, and types which can not be resolved.
+func (f *tmplFuncs) parentType(symbolPath string, relative ...interface{}) parentTypeEntry {
+	symbolPath = f.qualifyTypeName(symbolPath, relative...)
+	if symbolPath == "" {
+		return parentTypeEntry{}
+	}
+	resolver := util.NewResolver(f.protoFiles)
+	_, file := resolver.Resolve(symbolPath, nil)
+	if file == nil {
+		return parentTypeEntry{}
+	}
+	// Top-level types have a single element after the package.
+	if util.CountElem(symbolPath)-util.CountElem(file.GetPackage()) <= 1 {
+		return parentTypeEntry{}
+	}
+
+	parentPath := util.TrimElem(symbolPath, -1)
+	node, _ := resolver.Resolve(parentPath, nil)
+	msg, ok := node.(*descriptor.DescriptorProto)
+	if !ok {
+		return parentTypeEntry{}
+	}
+	return parentTypeEntry{FullName: parentPath, Message: msg}
+}
+
+// duplicateSimpleNames returns the simple names (the last element of the
+// symbol path) of messages, enums, and services which are shared by more than
+// one fully-qualified type in the request. The names are sorted.
+func duplicateSimpleNames(request *plugin.CodeGeneratorRequest) []string {
+	fullNames := make(map[string]map[string]bool)
+	add := func(file *descriptor.FileDescriptorProto, name string) {
+		simple := typeBaseName(name)
+		if fullNames[simple] == nil {
+			fullNames[simple] = make(map[string]bool)
+		}
+		fullNames[simple][util.FullName(file, name)] = true
+	}
+	for _, file := range request.GetProtoFile() {
+		for _, m := range util.AllMessages(file) {
+			add(file, m.GetName())
+		}
+		for _, e := range util.AllEnums(file) {
+			add(file, e.GetName())
+		}
+		for _, s := range file.GetService() {
+			add(file, s.GetName())
+		}
+	}
+
+	var duplicates []string
+	for simple, names := range fullNames {
+		if len(names) > 1 {
+			duplicates = append(duplicates, simple)
+		}
+	}
+	sort.Strings(duplicates)
+	return duplicates
+}
+
+// messageFullName returns the fully-qualified symbol path of the message, or
+// an empty string if the message is not declared in any of the proto files.
+// The message may be a copy of a nested message returned by allMessages.
+func (f *tmplFuncs) messageFullName(msg *descriptor.DescriptorProto) string {
+	var walk func(pkg, name string, messages []*descriptor.DescriptorProto) string
+	walk = func(pkg, name string, messages []*descriptor.DescriptorProto) string {
+		for _, m := range messages {
+			qualified := m.GetName()
+			if name != "" {
+				qualified = name + "." + qualified
+			}
+			if isMessage(msg, m, qualified) {
+				return pkg + "." + qualified
+			}
+			if found := walk(pkg, qualified, m.GetNestedType()); found != "" {
+				return found
+			}
+		}
+		return ""
+	}
+	for _, file := range f.protoFiles {
+		var pkg string
+		if file.GetPackage() != "" {
+			pkg = "." + file.GetPackage()
+		}
+		if found := walk(pkg, "", file.GetMessageType()); found != "" {
+			return found
+		}
+	}
+	return ""
+}
+
+// isMessage returns true if msg is the message m, or the copy of m returned by
+// util.AllMessages, which has the parent-qualified name of m, e.g.
+// "Outer.Inner", and is otherwise the same as m.
+func isMessage(msg, m *descriptor.DescriptorProto, qualified string) bool {
+	if msg == m {
+		return true
+	}
+	if msg.GetName() != qualified || qualified == m.GetName() {
+		return false
+	}
+	renamed := *msg
+	renamed.Name = m.Name
+	return proto.Equal(&renamed, m)
+}
+
+// enumFullName returns the fully-qualified symbol path of the enum, or an
+// empty string if the enum is not declared in any of the proto files. The enum
+// may be a copy of a nested enum returned by allEnums.
+func (f *tmplFuncs) enumFullName(enum *descriptor.EnumDescriptorProto) string {
+	find := func(pkg, name string, enums []*descriptor.EnumDescriptorProto) string {
+		for _, e := range enums {
+			qualified := e.GetName()
+			if name != "" {
+				qualified = name + "." + qualified
+			}
+			if matchesEnum(enum, e, qualified) {
+				return pkg + "." + qualified
+			}
+		}
+		return ""
+	}
+	var walk func(pkg, name string, messages []*descriptor.DescriptorProto) string
+	walk = func(pkg, name string, messages []*descriptor.DescriptorProto) string {
+		for _, m := range messages {
+			qualified := m.GetName()
+			if name != "" {
+				qualified = name + "." + qualified
+			}
+			if found := find(pkg, qualified, m.GetEnumType()); found != "" {
+				return found
+			}
+			if found := walk(pkg, qualified, m.GetNestedType()); found != "" {
+				return found
+			}
+		}
+		return ""
+	}
+	for _, file := range f.protoFiles {
+		var pkg string
+		if file.GetPackage() != "" {
+			pkg = "." + file.GetPackage()
+		}
+		if found := find(pkg, "", file.GetEnumType()); found != "" {
+			return found
+		}
+		if found := walk(pkg, "", file.GetMessageType()); found != "" {
+			return found
+		}
+	}
+	return ""
+}
+
+// matchesEnum returns true if enum is the enum e, or the copy of e returned by
+// util.AllEnums, see isMessage.
+func matchesEnum(enum, e *descriptor.EnumDescriptorProto, qualified string) bool {
+	if enum == e {
+		return true
+	}
+	if enum.GetName() != qualified || qualified == e.GetName() {
+		return false
+	}
+	renamed := *enum
+	renamed.Name = e.Name
+	return proto.Equal(&renamed, e)
+}
+
+// declaration returns the message or enum declared in the proto files of
+// which x is a copy returned by allMessages or allEnums, or x itself.
+func (f *tmplFuncs) declaration(x interface{}) interface{} {
+	var fullName string
+	switch v := x.(type) {
+	case *descriptor.DescriptorProto:
+		fullName = f.messageFullName(v)
+	case *descriptor.EnumDescriptorProto:
+		fullName = f.enumFullName(v)
+	}
+	if fullName == "" {
+		return x
+	}
+	if node, _ := util.NewResolver(f.protoFiles).Resolve(fullName, nil); node != nil {
+		return node
+	}
+	return x
+}
+
+// qualifiedType returns the name of a message or enum relative to its package,
+// with the names of the enclosing messages of nested types, e.g. "Outer.Inner"
+// for ".foo.Outer.Inner". The type may be a fully-qualified name, or a message
+// or enum descriptor. Descriptors which are not declared in the proto files,
+// such as the copies returned by allMessages, are named by their Name.
+func (f *tmplFuncs) qualifiedType(x interface{}) string {
+	var symbolPath string
+	switch v := x.(type) {
+	case string:
+		symbolPath = v
+	case *descriptor.DescriptorProto:
+		if symbolPath = f.messageFullName(v); symbolPath == "" {
+			return v.GetName()
+		}
+	case *descriptor.EnumDescriptorProto:
+		if symbolPath = f.enumFullName(v); symbolPath == "" {
+			return v.GetName()
+		}
+	default:
+		return ""
+	}
+
+	_, file := util.NewResolver(f.protoFiles).Resolve(symbolPath, nil)
+	if file == nil {
+		return strings.TrimPrefix(symbolPath, ".")
+	}
+	return strings.TrimPrefix(symbolPath, util.FullName(file, ""))
+}
+
+// declarationOrder returns the index of each message and enum in the file in
+// declaration order. Nested types follow the message which declares them,
+// messages before enums. The descriptor keeps top-level messages and enums in
+// separate lists, so top-level enums are ordered after all messages.
+func declarationOrder(file *descriptor.FileDescriptorProto) map[interface{}]int {
+	order := make(map[interface{}]int)
+	var walk func(msg *descriptor.DescriptorProto)
+	walk = func(msg *descriptor.DescriptorProto) {
+		order[msg] = len(order)
+		for _, nested := range msg.GetNestedType() {
+			walk(nested)
+		}
+		for _, enum := range msg.GetEnumType() {
+			order[enum] = len(order)
+		}
+	}
+	for _, msg := range file.GetMessageType() {
+		walk(msg)
+	}
+	for _, enum := range file.GetEnumType() {
+		order[enum] = len(order)
+	}
+	return order
+}
+
+// isForwardRef returns true if the type of the field is declared after msg in
+// the same file. The message may be a copy of a nested message returned by
+// allMessages.
+func (f *tmplFuncs) isForwardRef(msg *descriptor.DescriptorProto, field *descriptor.FieldDescriptorProto) bool {
+	if field.GetTypeName() == "" {
+		return false
+	}
+	resolver := util.NewResolver(f.protoFiles)
+	node, file := resolver.Resolve(field.GetTypeName(), nil)
+	if file == nil {
+		return false
+	}
+	fullName := f.messageFullName(msg)
+	if fullName == "" {
+		return false
+	}
+	// Look up the original declaration, which is the key in the order.
+	original, msgFile := resolver.Resolve(fullName, nil)
+	if msgFile != file {
+		return false // declared in another file
+	}
+	order := declarationOrder(file)
+	msgIndex, ok := order[original]
+	if !ok {
+		return false
+	}
+	typeIndex, ok := order[node]
+	return ok && typeIndex > msgIndex
+}
+
+// emptyTypeName is the fully-qualified name of the well-known Empty message.
+const emptyTypeName = ".google.protobuf.Empty"
+
+// isEmptyMessage returns true if the message has no fields. The message may be
+// a message descriptor, or a fully-qualified type name such as the input type
+// of a method. The well-known google.protobuf.Empty is always empty, even when
+// empty.proto is not included in the request.
+func (f *tmplFuncs) isEmptyMessage(x interface{}) bool {
+	var msg *descriptor.DescriptorProto
+	switch v := x.(type) {
+	case *descriptor.DescriptorProto:
+		msg = v
+	case string:
+		if v == emptyTypeName {
+			return true
+		}
+		node, _ := util.NewResolver(f.protoFiles).Resolve(v, nil)
+		msg, _ = node.(*descriptor.DescriptorProto)
+	}
+	return msg != nil && len(msg.GetField()) == 0
+}
+
+// wellKnownPackage is the package of the well-known types.
+const wellKnownPackage = ".google.protobuf."
+
+// inlineSubMessages returns the message types of the fields of the message, in
+// the order of the fields, with each type listed once. Map entries, well-known
+// types, and types which can not be resolved are excluded.
+func (f *tmplFuncs) inlineSubMessages(msg *descriptor.DescriptorProto) []*descriptor.DescriptorProto {
+	var (
+		messages []*descriptor.DescriptorProto
+		seen     = make(map[*descriptor.DescriptorProto]bool)
+		resolver = util.NewResolver(f.protoFiles)
+	)
+	for _, field := range msg.GetField() {
+		if field.GetType() != descriptor.FieldDescriptorProto_TYPE_MESSAGE ||
+			strings.HasPrefix(field.GetTypeName(), wellKnownPackage) {
+			continue
+		}
+		node, _ := resolver.Resolve(field.GetTypeName(), nil)
+		sub, ok := node.(*descriptor.DescriptorProto)
+		if !ok || seen[sub] || sub.GetOptions().GetMapEntry() {
+			continue
+		}
+		seen[sub] = true
+		messages = append(messages, sub)
+	}
+	return messages
+}
@@ -0,0 +1,258 @@
+package tmpl
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dnephin/proto-gen-html/util"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
+)
+
+func newNestedTypesFile() *descriptor.FileDescriptorProto {
+	return &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+		MessageType: []*descriptor.DescriptorProto{
+			{
+				Name: proto.String("Outer"),
+				NestedType: []*descriptor.DescriptorProto{
+					{Name: proto.String("Inner")},
+				},
+			},
+		},
+	}
+}
+
+func TestParentType(t *testing.T) {
+	f := newTestFuncs(Config{}, newNestedTypesFile())
+	got := f.parentType(".foo.Outer.Inner")
+	if got.FullName != ".foo.Outer" {
+		t.Fatalf("got %q expected %q", got.FullName, ".foo.Outer")
+	}
+	if got.Message != f.protoFiles[0].MessageType[0] {
+		t.Fatalf("got %v expected the Outer descriptor", got.Message)
+	}
+}
+
+func TestParentTypeTopLevel(t *testing.T) {
+	f := newTestFuncs(Config{}, newNestedTypesFile())
+	got := f.parentType(".foo.Outer")
+	if got.FullName != "" || got.Message != nil {
+		t.Fatalf("expected an empty parent, got %+v", got)
+	}
+}
+
+func TestParentTypeRelative(t *testing.T) {
+	f := newTestFuncs(Config{}, newNestedTypesFile())
+	got := f.parentType("Outer.Inner")
+	if got.FullName != ".foo.Outer" || got.Message != f.protoFiles[0].MessageType[0] {
+		t.Fatalf("got %+v expected the Outer descriptor", got)
+	}
+
+	for _, name := range []string{"Missing.Inner", ".foo.Missing.Inner"} {
+		if got := f.parentType(name); got.FullName != "" || got.Message != nil {
+			t.Fatalf("%s: expected an empty parent, got %+v", name, got)
+		}
+	}
+}
+
+func TestDuplicateSimpleNames(t *testing.T) {
+	request := &plugin.CodeGeneratorRequest{
+		ProtoFile: []*descriptor.FileDescriptorProto{
+			{
+				Name:    proto.String("a.proto"),
+				Package: proto.String("a"),
+				MessageType: []*descriptor.DescriptorProto{
+					{Name: proto.String("Status")},
+					{Name: proto.String("Request")},
+				},
+			},
+			{
+				Name:    proto.String("b.proto"),
+				Package: proto.String("b"),
+				MessageType: []*descriptor.DescriptorProto{
+					{Name: proto.String("Status")},
+				},
+			},
+		},
+	}
+	got := duplicateSimpleNames(request)
+	expected := []string{"Status"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %v expected %v", got, expected)
+	}
+}
+
+func TestIsForwardRef(t *testing.T) {
+	earlier := &descriptor.DescriptorProto{Name: proto.String("Earlier")}
+	current := &descriptor.DescriptorProto{
+		Name: proto.String("Current"),
+		Field: []*descriptor.FieldDescriptorProto{
+			{Name: proto.String("earlier"), TypeName: proto.String(".foo.Earlier")},
+			{Name: proto.String("later"), TypeName: proto.String(".foo.Later")},
+			{Name: proto.String("scalar"), Type: descriptor.FieldDescriptorProto_TYPE_INT32.Enum()},
+		},
+	}
+	later := &descriptor.DescriptorProto{Name: proto.String("Later")}
+	file := &descriptor.FileDescriptorProto{
+		Name:        proto.String("foo.proto"),
+		Package:     proto.String("foo"),
+		MessageType: []*descriptor.DescriptorProto{earlier, current, later},
+	}
+	f := &tmplFuncs{protoFiles: []*descriptor.FileDescriptorProto{file}}
+
+	if f.isForwardRef(current, current.Field[0]) {
+		t.Fatal("expected a type declared earlier not to be a forward reference")
+	}
+	if !f.isForwardRef(current, current.Field[1]) {
+		t.Fatal("expected a type declared later to be a forward reference")
+	}
+	if f.isForwardRef(current, current.Field[2]) {
+		t.Fatal("expected a scalar not to be a forward reference")
+	}
+}
+
+func TestIsForwardRefNestedMessage(t *testing.T) {
+	file := &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+		MessageType: []*descriptor.DescriptorProto{
+			{Name: proto.String("Earlier")},
+			{
+				Name: proto.String("Outer"),
+				NestedType: []*descriptor.DescriptorProto{
+					{
+						Name: proto.String("Inner"),
+						Field: []*descriptor.FieldDescriptorProto{
+							{Name: proto.String("earlier"), TypeName: proto.String(".foo.Earlier")},
+							{Name: proto.String("later"), TypeName: proto.String(".foo.Later")},
+						},
+					},
+				},
+			},
+			{Name: proto.String("Later")},
+		},
+	}
+	f := &tmplFuncs{protoFiles: []*descriptor.FileDescriptorProto{file}}
+	// The copy of Outer.Inner, as returned by allMessages.
+	inner := util.AllMessages(file)[2]
+
+	if got := f.messageFullName(inner); got != ".foo.Outer.Inner" {
+		t.Fatalf("got %q expected %q", got, ".foo.Outer.Inner")
+	}
+	if f.isForwardRef(inner, inner.Field[0]) {
+		t.Fatal("expected a type declared earlier not to be a forward reference")
+	}
+	if !f.isForwardRef(inner, inner.Field[1]) {
+		t.Fatal("expected a type declared later to be a forward reference")
+	}
+}
+
+func TestIsEmptyMessage(t *testing.T) {
+	file := &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+		MessageType: []*descriptor.DescriptorProto{
+			{Name: proto.String("Nothing")},
+			{
+				Name:  proto.String("Something"),
+				Field: []*descriptor.FieldDescriptorProto{{Name: proto.String("id"), Number: proto.Int32(1)}},
+			},
+		},
+	}
+	f := &tmplFuncs{protoFiles: []*descriptor.FileDescriptorProto{file}}
+
+	var testCases = []struct {
+		doc      string
+		x        interface{}
+		expected bool
+	}{
+		{doc: "zero fields", x: file.MessageType[0], expected: true},
+		{doc: "zero fields by name", x: ".foo.Nothing", expected: true},
+		{doc: "well-known Empty", x: ".google.protobuf.Empty", expected: true},
+		{doc: "with fields", x: file.MessageType[1], expected: false},
+		{doc: "with fields by name", x: ".foo.Something", expected: false},
+		{doc: "unknown type", x: ".foo.Missing", expected: false},
+	}
+	for _, testCase := range testCases {
+		if got := f.isEmptyMessage(testCase.x); got != testCase.expected {
+			t.Errorf("%s: got %v expected %v", testCase.doc, got, testCase.expected)
+		}
+	}
+}
+
+func TestInlineSubMessages(t *testing.T) {
+	messageField := func(name, typeName string) *descriptor.FieldDescriptorProto {
+		return &descriptor.FieldDescriptorProto{
+			Name:     proto.String(name),
+			Type:     descriptor.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+			TypeName: proto.String(typeName),
+		}
+	}
+	entry := &descriptor.DescriptorProto{
+		Name:    proto.String("LabelsEntry"),
+		Options: &descriptor.MessageOptions{MapEntry: proto.Bool(true)},
+	}
+	thing := &descriptor.DescriptorProto{
+		Name:       proto.String("Thing"),
+		NestedType: []*descriptor.DescriptorProto{entry},
+		Field: []*descriptor.FieldDescriptorProto{
+			messageField("owner", ".foo.User"),
+			messageField("created", ".google.protobuf.Timestamp"),
+			messageField("labels", ".foo.Thing.LabelsEntry"),
+			messageField("parts", ".foo.Part"),
+			messageField("editor", ".foo.User"),
+			{Name: proto.String("name"), Type: descriptor.FieldDescriptorProto_TYPE_STRING.Enum()},
+		},
+	}
+	user := &descriptor.DescriptorProto{Name: proto.String("User")}
+	part := &descriptor.DescriptorProto{Name: proto.String("Part")}
+	file := &descriptor.FileDescriptorProto{
+		Name:        proto.String("foo.proto"),
+		Package:     proto.String("foo"),
+		MessageType: []*descriptor.DescriptorProto{thing, user, part},
+	}
+	f := &tmplFuncs{protoFiles: []*descriptor.FileDescriptorProto{file}}
+
+	got := f.inlineSubMessages(thing)
+	expected := []*descriptor.DescriptorProto{user, part}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %v expected %v", got, expected)
+	}
+}
+
+func TestQualifiedType(t *testing.T) {
+	deep := &descriptor.DescriptorProto{Name: proto.String("Deep")}
+	kind := &descriptor.EnumDescriptorProto{Name: proto.String("Kind")}
+	inner := &descriptor.DescriptorProto{
+		Name:       proto.String("Inner"),
+		NestedType: []*descriptor.DescriptorProto{deep},
+		EnumType:   []*descriptor.EnumDescriptorProto{kind},
+	}
+	file := &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo.v1"),
+		MessageType: []*descriptor.DescriptorProto{
+			{Name: proto.String("Outer"), NestedType: []*descriptor.DescriptorProto{inner}},
+		},
+	}
+	f := &tmplFuncs{protoFiles: []*descriptor.FileDescriptorProto{file}}
+
+	var testCases = []struct {
+		node     interface{}
+		expected string
+	}{
+		{node: deep, expected: "Outer.Inner.Deep"},
+		{node: inner, expected: "Outer.Inner"},
+		{node: kind, expected: "Outer.Inner.Kind"},
+		{node: ".foo.v1.Outer.Inner.Deep", expected: "Outer.Inner.Deep"},
+		{node: &descriptor.DescriptorProto{Name: proto.String("Outer.Copy")}, expected: "Outer.Copy"},
+	}
+	for _, testCase := range testCases {
+		if got := f.qualifiedType(testCase.node); got != testCase.expected {
+			t.Errorf("got %q expected %q", got, testCase.expected)
+		}
+	}
+}
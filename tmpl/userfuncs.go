@@ -0,0 +1,48 @@
+package tmpl
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// validateFuncs returns an error if any of the Config.Funcs has the name of a
+// builtin function, or a signature which can not be called from a template.
+// html/template panics on invalid functions, so they are checked before any
+// template is parsed.
+func validateFuncs(config Config) error {
+	builtin := newDefaultTemplateFuncs()
+	var names []string
+	for name := range config.Funcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, ok := builtin[name]; ok {
+			return errors.Errorf("template function %q conflicts with a builtin function", name)
+		}
+		if err := checkFuncSignature(config.Funcs[name]); err != nil {
+			return errors.Wrapf(err, "invalid template function %q", name)
+		}
+	}
+	return nil
+}
+
+func checkFuncSignature(fn interface{}) error {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return errors.Errorf("expected a function, got %T", fn)
+	}
+	switch t := v.Type(); {
+	case t.NumOut() == 1:
+		return nil
+	case t.NumOut() == 2 && t.Out(1) == errorType:
+		return nil
+	default:
+		return errors.New("must return one value, or two values where the second is an error")
+	}
+}
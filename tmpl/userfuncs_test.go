@@ -0,0 +1,62 @@
+package tmpl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestGenerateWithUserFuncs(t *testing.T) {
+	request := newTestRequest(&descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+	})
+	config := Config{
+		TemplateRoot: testdataRoot(t),
+		Operations: []OperationConfig{
+			{Template: "user_funcs.html", Target: "foo.proto", Output: "foo.html"},
+		},
+		Funcs: map[string]interface{}{
+			"shout": func(s string) string { return strings.ToUpper(s) + "!" },
+		},
+	}
+	response, err := Generate(request, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Error != nil {
+		t.Fatal(response.GetError())
+	}
+	if got := response.File[0].GetContent(); got != "FOO!" {
+		t.Fatalf("got %q expected %q", got, "FOO!")
+	}
+}
+
+func TestValidateFuncs(t *testing.T) {
+	var tests = map[string]struct {
+		fn       interface{}
+		expected string
+	}{
+		"typeURL":  {func() string { return "" }, `template function "typeURL" conflicts with a builtin function`},
+		"notAFunc": {"value", "expected a function, got string"},
+		"noResult": {func() {}, "must return one value"},
+		"notError": {func() (string, string) { return "", "" }, "must return one value"},
+	}
+	for name, test := range tests {
+		err := validateFuncs(Config{Funcs: map[string]interface{}{name: test.fn}})
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("%s: expected error %q, got %v", name, test.expected, err)
+		}
+	}
+
+	valid := map[string]interface{}{
+		"one":      func(int) string { return "" },
+		"withErr":  func(...string) (string, error) { return "", nil },
+		"anything": func(interface{}) interface{} { return nil },
+	}
+	if err := validateFuncs(Config{Funcs: valid}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
@@ -0,0 +1,95 @@
+package tmpl
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
+	"github.com/pkg/errors"
+)
+
+// Validate checks the config for the request, and returns an error which lists
+// every problem found. Each operation must have an Output, a Target which is
+// empty, one of the input proto files, or a pattern which matches at least one
+// of them, and readable template files.
+func (c Config) Validate(request *plugin.CodeGeneratorRequest) error {
+	var problems []string
+	if err := validateFuncs(c); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	g := &generator{config: c, request: request}
+	for i, opConfig := range c.Operations {
+		for _, problem := range g.validateOperation(opConfig) {
+			problems = append(problems, fmt.Sprintf("operation %d (output %q): %s", i, opConfig.Output, problem))
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return errors.Errorf("invalid config:\n  %s", strings.Join(problems, "\n  "))
+}
+
+func (g *generator) validateOperation(opConfig OperationConfig) []string {
+	var problems []string
+	if opConfig.Output == "" {
+		problems = append(problems, "output is empty")
+	}
+
+	switch {
+	case opConfig.Target == "":
+	case isTargetPattern(opConfig.Target):
+		if _, err := g.expandTarget(opConfig); err != nil {
+			problems = append(problems, err.Error())
+		}
+	case getProtoFileFromTarget(opConfig.Target, g.request) == nil:
+		problems = append(problems, fmt.Sprintf("no input proto file for generator target %q", opConfig.Target))
+	}
+
+	templates := map[string]string{"wrap": opConfig.Wrap, "fallback": opConfig.Fallback}
+	if opConfig.Format == "" {
+		templates["template"] = opConfig.Template
+		if opConfig.Template == "" {
+			if _, ok := builtinTemplates[g.builtinTemplateName()]; !ok {
+				problems = append(problems, fmt.Sprintf("unknown builtin template %q", g.builtinTemplateName()))
+			}
+		}
+	}
+	for _, kind := range []string{"template", "fallback", "wrap"} {
+		name := templates[kind]
+		if name == "" {
+			continue
+		}
+		op := opConfig
+		op.Template = name
+		if err := g.checkTemplateFile(op); err != nil && kind == "template" {
+			problems = append(problems, err.Error())
+		} else if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", kind, err))
+		}
+	}
+	return problems
+}
+
+// checkTemplateFile returns an error if the template of the operation can not
+// be read.
+func (g *generator) checkTemplateFile(opConfig OperationConfig) error {
+	fullPath, err := g.templatePath(opConfig)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(fullPath)
+	if err != nil {
+		return errors.Wrapf(err, "template %s can not be read", opConfig.Template)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return errors.Wrapf(err, "template %s can not be read", opConfig.Template)
+	}
+	if info.IsDir() {
+		return errors.Errorf("template %s is a directory", opConfig.Template)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package tmpl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestConfigValidate(t *testing.T) {
+	request := newTestRequest(&descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+	})
+	config := Config{
+		TemplateRoot: testdataRoot(t),
+		Operations: []OperationConfig{
+			{Template: "target.html", Target: "foo.proto", Output: "foo.html"},
+			{Template: "target.html", Target: "foo.proto"},
+			{Template: "missing.html", Output: "missing.html"},
+			{Template: "target.html", Target: "bar.proto", Output: "bar.html"},
+			{Template: "target.html", Target: "api/*.proto", Output: "{{.Name}}.html"},
+			{Template: "partials", Output: "dir.html"},
+			{Template: "target.html", Wrap: "nowrap.html", Output: "wrapped.html"},
+			{Format: formatManifest, Output: "manifest.json"},
+		},
+	}
+	err := config.Validate(request)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	expected := []string{
+		"invalid config:",
+		`operation 1 (output ""): output is empty`,
+		`operation 2 (output "missing.html"): template missing.html does not exist in template root`,
+		`operation 3 (output "bar.html"): no input proto file for generator target "bar.proto"`,
+		`operation 4 (output "{{.Name}}.html"): no input proto files match generator target pattern "api/*.proto"`,
+		`operation 5 (output "dir.html"): template partials is a directory`,
+		`operation 6 (output "wrapped.html"): wrap: template nowrap.html does not exist`,
+	}
+	lines := strings.Split(err.Error(), "\n")
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %d lines, got %q", len(expected), err)
+	}
+	for i, line := range lines {
+		if !strings.Contains(line, expected[i]) {
+			t.Errorf("line %d: expected %q in %q", i, expected[i], line)
+		}
+	}
+}
+
+func TestConfigValidateValid(t *testing.T) {
+	request := newTestRequest(&descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+	})
+	config := Config{
+		TemplateRoot: testdataRoot(t),
+		Operations: []OperationConfig{
+			{Template: "target.html", Target: "foo.proto", Output: "foo.html"},
+			{Template: "target.html", Target: "*.proto", Output: "{{.Name}}.html"},
+			{Target: "foo.proto", Output: "builtin.html"},
+			{Format: formatSearch, Output: "search.json"},
+		},
+	}
+	if err := config.Validate(request); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
@@ -0,0 +1,95 @@
+package tmpl
+
+import (
+	"strconv"
+
+	"github.com/dnephin/proto-gen-html/util"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// visibilityNumber returns the number of the visibility option set on the
+// field, and false if it is not set or no VisibilityOption is configured.
+func (f *tmplFuncs) visibilityNumber(field *descriptor.FieldDescriptorProto) (int32, bool) {
+	if f.config.VisibilityOption == 0 || field.GetOptions() == nil {
+		return 0, false
+	}
+	// The option is not registered in this process, so it is read from the
+	// encoded options.
+	data, err := proto.Marshal(field.GetOptions())
+	if err != nil {
+		return 0, false
+	}
+	value, ok := unknownVarint(data, uint64(f.config.VisibilityOption))
+	return int32(value), ok
+}
+
+// fieldVisibility returns the name of the visibility option value set on the
+// field, or an empty string if it is not set. If the VisibilityEnum can not be
+// found the number of the value is returned.
+func (f *tmplFuncs) fieldVisibility(field *descriptor.FieldDescriptorProto) string {
+	number, ok := f.visibilityNumber(field)
+	if !ok {
+		return ""
+	}
+	if enum := f.visibilityEnum(); enum != nil {
+		for _, v := range enum.GetValue() {
+			if v.GetNumber() == number {
+				return v.GetName()
+			}
+		}
+	}
+	return strconv.Itoa(int(number))
+}
+
+// visibleFields returns the fields of the message which are at least as
+// visible as Config.MinVisibility, which is the order the values are declared
+// in the VisibilityEnum. Fields without a visibility option have the
+// visibility of the enum value numbered 0. Fields with a value which is not in
+// the VisibilityEnum are excluded.
+func (f *tmplFuncs) visibleFields(msg *descriptor.DescriptorProto) []*descriptor.FieldDescriptorProto {
+	enum := f.visibilityEnum()
+	if f.config.MinVisibility == "" || enum == nil {
+		return msg.GetField()
+	}
+	min := -1
+	for i, v := range enum.GetValue() {
+		if v.GetName() == f.config.MinVisibility {
+			min = i
+			break
+		}
+	}
+	if min < 0 {
+		return msg.GetField()
+	}
+
+	var fields []*descriptor.FieldDescriptorProto
+	for _, field := range msg.GetField() {
+		if index := f.visibilityIndex(enum, field); index >= 0 && index <= min {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// visibilityIndex returns the index of the value of the enum which is the
+// visibility of the field, see visibilityNumber, or -1 if the enum has no value
+// with its number.
+func (f *tmplFuncs) visibilityIndex(enum *descriptor.EnumDescriptorProto, field *descriptor.FieldDescriptorProto) int {
+	number, _ := f.visibilityNumber(field)
+	for i, v := range enum.GetValue() {
+		if v.GetNumber() == number {
+			return i
+		}
+	}
+	return -1
+}
+
+func (f *tmplFuncs) visibilityEnum() *descriptor.EnumDescriptorProto {
+	if f.config.VisibilityEnum == "" {
+		return nil
+	}
+	node, _ := util.NewResolver(f.protoFiles).Resolve(f.config.VisibilityEnum, nil)
+	enum, _ := node.(*descriptor.EnumDescriptorProto)
+	return enum
+}
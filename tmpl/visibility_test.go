@@ -0,0 +1,115 @@
+package tmpl
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+const testVisibilityOption = 50001
+
+func visibilityField(name string, visibility uint64) *descriptor.FieldDescriptorProto {
+	options := &descriptor.FieldOptions{}
+	value := append(proto.EncodeVarint(testVisibilityOption<<3), proto.EncodeVarint(visibility)...)
+	proto.SetRawExtension(options, testVisibilityOption, value)
+	return &descriptor.FieldDescriptorProto{Name: proto.String(name), Options: options}
+}
+
+// visibilityConfig returns a config which reads the visibility of fields from
+// the testVisibilityOption, with the values of the enum of newVisibilityFile.
+func visibilityConfig(minVisibility string) Config {
+	return Config{
+		VisibilityOption: testVisibilityOption,
+		VisibilityEnum:   ".opts.Visibility",
+		MinVisibility:    minVisibility,
+	}
+}
+
+func newVisibilityFile() *descriptor.FileDescriptorProto {
+	return &descriptor.FileDescriptorProto{
+		Name:    proto.String("visibility.proto"),
+		Package: proto.String("opts"),
+		EnumType: []*descriptor.EnumDescriptorProto{
+			{
+				Name: proto.String("Visibility"),
+				Value: []*descriptor.EnumValueDescriptorProto{
+					enumValue("PUBLIC", 0),
+					enumValue("INTERNAL", 1),
+					enumValue("PRIVATE", 2),
+				},
+			},
+		},
+	}
+}
+
+func TestFieldVisibility(t *testing.T) {
+	f := newTestFuncs(visibilityConfig(""), newVisibilityFile())
+	if got := f.fieldVisibility(visibilityField("secret", 2)); got != "PRIVATE" {
+		t.Fatalf("got %q expected %q", got, "PRIVATE")
+	}
+	plain := &descriptor.FieldDescriptorProto{Name: proto.String("plain")}
+	if got := f.fieldVisibility(plain); got != "" {
+		t.Fatalf("got %q expected no visibility", got)
+	}
+}
+
+func TestVisibleFields(t *testing.T) {
+	msg := &descriptor.DescriptorProto{
+		Name: proto.String("Foo"),
+		Field: []*descriptor.FieldDescriptorProto{
+			{Name: proto.String("plain")},
+			visibilityField("public", 0),
+			visibilityField("internal", 1),
+			visibilityField("private", 2),
+		},
+	}
+	var tests = map[string][]string{
+		"":         {"plain", "public", "internal", "private"},
+		"PUBLIC":   {"plain", "public"},
+		"INTERNAL": {"plain", "public", "internal"},
+	}
+	for min, expected := range tests {
+		got := newTestFuncs(visibilityConfig(min), newVisibilityFile()).visibleFields(msg)
+		var names []string
+		for _, field := range got {
+			names = append(names, field.GetName())
+		}
+		if len(names) != len(expected) {
+			t.Fatalf("min %q: got %v expected %v", min, names, expected)
+		}
+		for i := range names {
+			if names[i] != expected[i] {
+				t.Fatalf("min %q: got %v expected %v", min, names, expected)
+			}
+		}
+	}
+}
+
+func TestVisibleFieldsDeclarationOrder(t *testing.T) {
+	f := newTestFuncs(visibilityConfig("INTERNAL"), newVisibilityFile())
+	// The values are ordered by declaration, not by number.
+	f.protoFiles[0].EnumType[0].Value = []*descriptor.EnumValueDescriptorProto{
+		enumValue("PUBLIC", 0),
+		enumValue("INTERNAL", 5),
+		enumValue("PRIVATE", 3),
+	}
+	msg := &descriptor.DescriptorProto{
+		Name: proto.String("Foo"),
+		Field: []*descriptor.FieldDescriptorProto{
+			{Name: proto.String("plain")},
+			visibilityField("internal", 5),
+			visibilityField("private", 3),
+			visibilityField("unknown", 4),
+		},
+	}
+	var names []string
+	for _, field := range f.visibleFields(msg) {
+		names = append(names, field.GetName())
+	}
+	expected := []string{"plain", "internal"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("got %v expected %v", names, expected)
+	}
+}
@@ -0,0 +1,75 @@
+package tmpl
+
+import (
+	"strings"
+
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// jsonMappingNotes are the notes on the special JSON representation of the
+// well-known types, keyed by their fully-qualified symbol path.
+var jsonMappingNotes = map[string]string{
+	".google.protobuf.Any":         `Any is encoded as a JSON object with an "@type" field containing the type URL, and the fields of the embedded message.`,
+	".google.protobuf.Duration":    `Duration is encoded as a string of seconds with an "s" suffix, e.g. "1.000340012s".`,
+	".google.protobuf.Empty":       `Empty is encoded as an empty JSON object.`,
+	".google.protobuf.FieldMask":   `FieldMask is encoded as a string of comma-separated lowerCamelCase paths, e.g. "user.displayName,photo".`,
+	".google.protobuf.ListValue":   `ListValue is encoded as a JSON array.`,
+	".google.protobuf.Struct":      `Struct is encoded as a JSON object.`,
+	".google.protobuf.Timestamp":   `Timestamp is encoded as an RFC 3339 string, e.g. "1972-01-01T10:00:20.021Z".`,
+	".google.protobuf.Value":       `Value is encoded as the JSON value it contains.`,
+	".google.protobuf.BoolValue":   `BoolValue is encoded as a JSON boolean.`,
+	".google.protobuf.BytesValue":  `BytesValue is encoded as a base64 JSON string.`,
+	".google.protobuf.DoubleValue": `DoubleValue is encoded as a JSON number.`,
+	".google.protobuf.FloatValue":  `FloatValue is encoded as a JSON number.`,
+	".google.protobuf.Int32Value":  `Int32Value is encoded as a JSON number.`,
+	".google.protobuf.Int64Value":  `Int64Value is encoded as a JSON string.`,
+	".google.protobuf.StringValue": `StringValue is encoded as a JSON string.`,
+	".google.protobuf.UInt32Value": `UInt32Value is encoded as a JSON number.`,
+	".google.protobuf.UInt64Value": `UInt64Value is encoded as a JSON string.`,
+}
+
+// jsonMappingNote returns a note on the JSON representation of the message if
+// it is one of the well-known types with a special JSON mapping. It returns an
+// empty string for all other messages.
+func (f *tmplFuncs) jsonMappingNote(msg *descriptor.DescriptorProto) string {
+	return jsonMappingNotes[f.messageFullName(msg)]
+}
+
+// defaultExternalTypeURLs are the URLs of the documentation of the well-known
+// packages, see Config.ExternalTypeURLs.
+var defaultExternalTypeURLs = map[string]string{
+	"google.protobuf": "https://protobuf.dev/reference/protobuf/google.protobuf/#{lowerName}",
+	"google.api":      "https://cloud.google.com/service-infrastructure/docs/service-management/reference/rpc/google.api#{fullName}",
+	"google.type":     "https://cloud.google.com/service-infrastructure/docs/service-management/reference/rpc/google.type#{fullName}",
+}
+
+// externalTypeURL returns the URL of the external documentation of the type
+// with the fully-qualified symbolPath, or an empty string if the package of the
+// type has no external documentation. When more than one package matches, the
+// longest one is used.
+func (f *tmplFuncs) externalTypeURL(symbolPath string) string {
+	var pkg, pattern string
+	match := func(p, url string) {
+		if url != "" && strings.HasPrefix(symbolPath, "."+p+".") && len(p) > len(pkg) {
+			pkg, pattern = p, url
+		}
+	}
+	for p, url := range defaultExternalTypeURLs {
+		if _, ok := f.config.ExternalTypeURLs[p]; !ok {
+			match(p, url)
+		}
+	}
+	for p, url := range f.config.ExternalTypeURLs {
+		match(p, url)
+	}
+	if pattern == "" {
+		return ""
+	}
+
+	name := strings.TrimPrefix(symbolPath, "."+pkg+".")
+	return strings.NewReplacer(
+		"{name}", name,
+		"{lowerName}", strings.ToLower(name),
+		"{fullName}", strings.TrimPrefix(symbolPath, "."),
+	).Replace(pattern)
+}
@@ -0,0 +1,94 @@
+package tmpl
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestJSONMappingNote(t *testing.T) {
+	timestamp := &descriptor.DescriptorProto{Name: proto.String("Timestamp")}
+	user := &descriptor.DescriptorProto{Name: proto.String("Timestamp")}
+	f := &tmplFuncs{
+		protoFiles: []*descriptor.FileDescriptorProto{
+			{
+				Name:        proto.String("google/protobuf/timestamp.proto"),
+				Package:     proto.String("google.protobuf"),
+				MessageType: []*descriptor.DescriptorProto{timestamp},
+			},
+			{
+				Name:        proto.String("foo.proto"),
+				Package:     proto.String("foo"),
+				MessageType: []*descriptor.DescriptorProto{user},
+			},
+		},
+	}
+
+	expected := `Timestamp is encoded as an RFC 3339 string, e.g. "1972-01-01T10:00:20.021Z".`
+	if got := f.jsonMappingNote(timestamp); got != expected {
+		t.Fatalf("got %q expected %q", got, expected)
+	}
+	if got := f.jsonMappingNote(user); got != "" {
+		t.Fatalf("got %q expected no note for a user message", got)
+	}
+}
+
+func TestTypeURLExternal(t *testing.T) {
+	file := &descriptor.FileDescriptorProto{
+		Name:    proto.String("foo.proto"),
+		Package: proto.String("foo"),
+		MessageType: []*descriptor.DescriptorProto{
+			{Name: proto.String("Foo")},
+		},
+	}
+	timestamp := &descriptor.FileDescriptorProto{
+		Name:    proto.String("google/protobuf/timestamp.proto"),
+		Package: proto.String("google.protobuf"),
+		MessageType: []*descriptor.DescriptorProto{
+			{Name: proto.String("Timestamp")},
+		},
+	}
+	f := &tmplFuncs{
+		outputFile: "foo.html",
+		protoFiles: []*descriptor.FileDescriptorProto{timestamp, file},
+		files:      []*descriptor.FileDescriptorProto{file},
+	}
+
+	var testCases = []struct {
+		symbolPath string
+		expected   string
+	}{
+		{
+			symbolPath: ".google.protobuf.Timestamp",
+			expected:   "https://protobuf.dev/reference/protobuf/google.protobuf/#timestamp",
+		},
+		{
+			// Not in the request.
+			symbolPath: ".google.protobuf.Any",
+			expected:   "https://protobuf.dev/reference/protobuf/google.protobuf/#any",
+		},
+		{
+			symbolPath: ".google.type.Date",
+			expected:   "https://cloud.google.com/service-infrastructure/docs/service-management/reference/rpc/google.type#google.type.Date",
+		},
+		{symbolPath: ".foo.Foo", expected: "foo.html#Foo"},
+		{symbolPath: ".other.Missing", expected: ""},
+	}
+	for _, testCase := range testCases {
+		if got := f.typeURL(testCase.symbolPath); got != testCase.expected {
+			t.Errorf("%s: got %q expected %q", testCase.symbolPath, got, testCase.expected)
+		}
+	}
+
+	f.config.ExternalTypeURLs = map[string]string{
+		"google.protobuf": "https://mirror.example.com/wkt/{name}.html",
+		"google.type":     "",
+	}
+	if got, expected := f.typeURL(".google.protobuf.Any"), "https://mirror.example.com/wkt/Any.html"; got != expected {
+		t.Errorf("got %q expected %q", got, expected)
+	}
+	if got := f.typeURL(".google.type.Date"); got != "" {
+		t.Errorf("expected disabled external URL, got %q", got)
+	}
+}
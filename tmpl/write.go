@@ -0,0 +1,39 @@
+package tmpl
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
+	"github.com/pkg/errors"
+)
+
+// WriteFiles writes the files in the response returned by Generate to dir. The
+// name of each file is a slash separated path relative to dir. If the response
+// contains an error it is returned and no files are written.
+func WriteFiles(response *plugin.CodeGeneratorResponse, dir string) error {
+	if response.Error != nil {
+		return errors.New(response.GetError())
+	}
+
+	for _, file := range response.GetFile() {
+		if file.GetInsertionPoint() != "" {
+			return errors.Errorf("insertion points are not supported: %s", file.GetName())
+		}
+		name := filepath.FromSlash(file.GetName())
+		if filepath.IsAbs(name) || strings.HasPrefix(filepath.Clean(name), "..") {
+			return errors.Errorf("file %s is outside of the output directory", file.GetName())
+		}
+
+		fullPath := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			return errors.Wrapf(err, "failed to create directory for %s", file.GetName())
+		}
+		if err := ioutil.WriteFile(fullPath, []byte(file.GetContent()), 0644); err != nil {
+			return errors.Wrapf(err, "failed to write %s", file.GetName())
+		}
+	}
+	return nil
+}
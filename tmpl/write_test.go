@@ -0,0 +1,57 @@
+package tmpl
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
+)
+
+func TestGenerateAndWriteFiles(t *testing.T) {
+	request := newTestRequest(&descriptor.FileDescriptorProto{
+		Name:    proto.String("foo/foo.proto"),
+		Package: proto.String("foo"),
+	})
+	config := Config{
+		TemplateRoot: testdataRoot(t),
+		Operations: []OperationConfig{
+			{Template: "target.html", Target: "foo/foo.proto", Output: "foo/foo.html"},
+		},
+	}
+	response, err := Generate(request, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "proto-gen-html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := WriteFiles(response, dir); err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadFile(filepath.Join(dir, "foo", "foo.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "foo/foo.proto\n" {
+		t.Fatalf("got %q expected %q", content, "foo/foo.proto\n")
+	}
+}
+
+func TestWriteFilesOutsideDir(t *testing.T) {
+	response := &plugin.CodeGeneratorResponse{
+		File: []*plugin.CodeGeneratorResponse_File{
+			{Name: proto.String("../escape.html"), Content: proto.String("")},
+		},
+	}
+	if err := WriteFiles(response, os.TempDir()); err == nil {
+		t.Fatal("expected an error for a file outside of the directory")
+	}
+}
@@ -22,80 +22,63 @@ func nameEnum(old *descriptor.EnumDescriptorProto, newName string) *descriptor.E
 	return &cpy
 }
 
-// appendEnum is a helper function for appending two symbol paths together. It's
-// not generic enough to be public (i.e. it can only work for the below use
-// cases).
-func appendElem(symbolPath, childName string) string {
-	if symbolPath == "" {
-		return childName + "."
+// appendElem is a helper function for appending a child type name to the
+// parent-qualified name of its parent. It's not generic enough to be public
+// (i.e. it can only work for the below use cases).
+func appendElem(parentName, childName string) string {
+	if parentName == "" {
+		return childName
 	}
-	return fmt.Sprintf("%s.%s", symbolPath, childName)
+	return fmt.Sprintf("%s.%s", parentName, childName)
 }
 
 // AllMessages returns a list of all the message type nodes in f, including
-// nested ones.
+// nested ones. Nested messages are copies of the original nodes, named by
+// their parent-qualified name, e.g. "Outer.Inner".
 func AllMessages(f *descriptor.FileDescriptorProto) []*descriptor.DescriptorProto {
 	var (
-		all        []*descriptor.DescriptorProto
-		walk       func(n *descriptor.DescriptorProto)
-		symbolPath string
+		all  []*descriptor.DescriptorProto
+		walk func(n *descriptor.DescriptorProto, name string)
 	)
 
 	// Define the function that will perform the recursive walk of the AST nodes.
-	walk = func(n *descriptor.DescriptorProto) {
-		// The name of the type should only ever be a single element.
-		if CountElem(n.GetName()) != 1 {
-			panic("unexpected name elements")
-		}
-
+	walk = func(n *descriptor.DescriptorProto, name string) {
 		for _, child := range n.NestedType {
-			// Accumulate the node and swap the names of it, if desired.
-			all = append(all, nameMessage(child, symbolPath+child.GetName()))
-
-			symbolPath = appendElem(symbolPath, child.GetName()) // push parent type name
-			walk(child)                                          // walk nested types, recursively
-			symbolPath = TrimElem(symbolPath, 1)                 // pop parent type name
+			// Accumulate the node and swap the names of it.
+			childName := appendElem(name, child.GetName())
+			all = append(all, nameMessage(child, childName))
+			walk(child, childName) // walk nested types, recursively
 		}
 	}
 
 	for _, m := range f.MessageType {
 		// Accumulate each root-level message type.
 		all = append(all, m)
-
-		symbolPath = appendElem(symbolPath, m.GetName()) // push parent type name
-		walk(m)                                          // walk nested types
-		symbolPath = TrimElem(symbolPath, 1)             // pop parent type name
+		walk(m, m.GetName())
 	}
 	return all
 }
 
 // AllEnums returnes a list of all the enum type nodes in f, including nested
-// ones.
+// ones. Nested enums are copies of the original nodes, named by their
+// parent-qualified name, e.g. "Outer.Kind".
 func AllEnums(f *descriptor.FileDescriptorProto) []*descriptor.EnumDescriptorProto {
 	var (
-		all        []*descriptor.EnumDescriptorProto
-		walk       func(n *descriptor.DescriptorProto)
-		symbolPath string
+		all  []*descriptor.EnumDescriptorProto
+		walk func(n *descriptor.DescriptorProto, name string)
 	)
 
 	// Define the function that will perform the recursive walk of the AST nodes.
-	walk = func(n *descriptor.DescriptorProto) {
-		// The name of the type should only ever be a single element.
-		if CountElem(n.GetName()) != 1 {
-			panic("unexpected name elements")
-		}
-
+	walk = func(n *descriptor.DescriptorProto, name string) {
 		for _, child := range n.EnumType {
-			// Accumulate the node, swapping the names of it if desired.
-			all = append(all, nameEnum(child, symbolPath+child.GetName()))
+			// Accumulate the node, swapping the names of it.
+			all = append(all, nameEnum(child, appendElem(name, child.GetName())))
 		}
 
 		// Walk the nested types for this message node, in case there are more child
 		// enum types.
 		for _, child := range n.NestedType {
-			symbolPath = appendElem(symbolPath, child.GetName()) // push parent type name
-			walk(child)                                          // walk nested types, recursively
-			symbolPath = TrimElem(symbolPath, 1)                 // pop parent type name
+			walk(child, appendElem(name, child.GetName()))
 		}
 	}
 
@@ -106,9 +89,7 @@ func AllEnums(f *descriptor.FileDescriptorProto) []*descriptor.EnumDescriptorPro
 
 	// Walk each root-level message type for nested enums.
 	for _, m := range f.MessageType {
-		symbolPath = appendElem(symbolPath, m.GetName()) // push parent type name
-		walk(m)                                          // walk nested types, recursively
-		symbolPath = TrimElem(symbolPath, 1)             // pop parent type name
+		walk(m, m.GetName())
 	}
 	return all
 }
@@ -0,0 +1,50 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	descriptor "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestAllMessagesAndEnumsNested(t *testing.T) {
+	f := &descriptor.FileDescriptorProto{
+		MessageType: []*descriptor.DescriptorProto{
+			{
+				Name: proto.String("Outer"),
+				NestedType: []*descriptor.DescriptorProto{
+					{
+						Name: proto.String("Inner"),
+						NestedType: []*descriptor.DescriptorProto{
+							{
+								Name:     proto.String("Deep"),
+								EnumType: []*descriptor.EnumDescriptorProto{{Name: proto.String("Kind")}},
+							},
+						},
+					},
+					{Name: proto.String("Sibling")},
+				},
+			},
+			{Name: proto.String("Other")},
+		},
+	}
+
+	var messages []string
+	for _, m := range AllMessages(f) {
+		messages = append(messages, m.GetName())
+	}
+	expected := []string{"Outer", "Outer.Inner", "Outer.Inner.Deep", "Outer.Sibling", "Other"}
+	if !reflect.DeepEqual(messages, expected) {
+		t.Fatalf("got %v expected %v", messages, expected)
+	}
+
+	var enums []string
+	for _, e := range AllEnums(f) {
+		enums = append(enums, e.GetName())
+	}
+	expected = []string{"Outer.Inner.Deep.Kind"}
+	if !reflect.DeepEqual(enums, expected) {
+		t.Fatalf("got %v expected %v", enums, expected)
+	}
+}
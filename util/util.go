@@ -151,3 +151,15 @@ func ReadJSONFile(path string) (*plugin.CodeGeneratorRequest, error) {
 	}
 	return r, nil
 }
+
+// FullName returns the fully-qualified symbol path of the named type declared
+// in f. The name must be relative to the package of f, for example:
+//
+//  FullName(f, "Outer.Inner") == ".pkg.Outer.Inner"
+//
+func FullName(f *descriptor.FileDescriptorProto, name string) string {
+	if pkg := f.GetPackage(); len(pkg) > 0 {
+		return "." + pkg + "." + name
+	}
+	return "." + name
+}